@@ -0,0 +1,225 @@
+package api
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Client talks to a keylightctl daemon.
+type Client struct {
+	BaseURL    string
+	HTTPClient *http.Client
+	// Token, if set, is sent as a bearer token with every request.
+	Token string
+}
+
+func NewClient(baseURL string) *Client {
+	return &Client{
+		BaseURL:    strings.TrimRight(baseURL, "/"),
+		HTTPClient: &http.Client{},
+	}
+}
+
+// NewSocketClient returns a client for a daemon listening on a Unix socket.
+func NewSocketClient(socketPath string) *Client {
+	dialer := &net.Dialer{}
+	return &Client{
+		BaseURL: "http://keylightctl",
+		HTTPClient: &http.Client{
+			Transport: &http.Transport{
+				DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+					return dialer.DialContext(ctx, "unix", socketPath)
+				},
+			},
+		},
+	}
+}
+
+// DefaultSocketPath returns the Unix socket the daemon listens on by default:
+// keylightctl.sock in $XDG_RUNTIME_DIR, or a per-user file in the temporary
+// directory if that is not set.
+func DefaultSocketPath() string {
+	if dir := os.Getenv("XDG_RUNTIME_DIR"); dir != "" {
+		return filepath.Join(dir, "keylightctl.sock")
+	}
+	return filepath.Join(os.TempDir(), fmt.Sprintf("keylightctl-%d.sock", os.Getuid()))
+}
+
+func (c *Client) Lights(ctx context.Context) ([]Light, error) {
+	var lights []Light
+	err := c.do(ctx, http.MethodGet, "/api/v1/lights", nil, &lights)
+	return lights, err
+}
+
+func (c *Client) Light(ctx context.Context, name string) (*Light, error) {
+	var light Light
+	if err := c.do(ctx, http.MethodGet, "/api/v1/lights/"+url.PathEscape(name), nil, &light); err != nil {
+		return nil, err
+	}
+	return &light, nil
+}
+
+// RefreshLight is like Light, but the daemon reads the light rather than
+// answering from its cache.
+func (c *Client) RefreshLight(ctx context.Context, name string) (*Light, error) {
+	var light Light
+	if err := c.do(ctx, http.MethodGet, "/api/v1/lights/"+url.PathEscape(name)+"?refresh=1", nil, &light); err != nil {
+		return nil, err
+	}
+	return &light, nil
+}
+
+func (c *Client) PatchLight(ctx context.Context, name string, patch StatePatch) (*Light, error) {
+	var light Light
+	if err := c.do(ctx, http.MethodPatch, "/api/v1/lights/"+url.PathEscape(name), patch, &light); err != nil {
+		return nil, err
+	}
+	return &light, nil
+}
+
+func (c *Client) Groups(ctx context.Context) ([]Group, error) {
+	var groups []Group
+	err := c.do(ctx, http.MethodGet, "/api/v1/groups", nil, &groups)
+	return groups, err
+}
+
+func (c *Client) ToggleGroup(ctx context.Context, name string, req ToggleRequest) ([]Light, error) {
+	var lights []Light
+	err := c.do(ctx, http.MethodPost, "/api/v1/groups/"+url.PathEscape(name)+"/toggle", req, &lights)
+	return lights, err
+}
+
+func (c *Client) Scenes(ctx context.Context) ([]Scene, error) {
+	var scenes []Scene
+	err := c.do(ctx, http.MethodGet, "/api/v1/scenes", nil, &scenes)
+	return scenes, err
+}
+
+func (c *Client) ApplyScene(ctx context.Context, name string, req ApplySceneRequest) ([]Light, error) {
+	var lights []Light
+	err := c.do(ctx, http.MethodPost, "/api/v1/scenes/"+url.PathEscape(name)+"/apply", req, &lights)
+	return lights, err
+}
+
+func (c *Client) do(ctx context.Context, method, path string, body, out any) error {
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return fmt.Errorf("failed to marshal request: %w", err)
+		}
+		reader = bytes.NewReader(data)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, c.BaseURL+path, reader)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+	c.authorize(req)
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := c.HTTPClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return responseError(resp)
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("parsing response failed: %w", err)
+	}
+	return nil
+}
+
+// Subscribe streams the daemon's events and calls handle for each of them,
+// until ctx is done or the daemon closes the stream.
+func (c *Client) Subscribe(ctx context.Context, handle func(Event)) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.BaseURL+"/api/v1/events", nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "text/event-stream")
+	c.authorize(req)
+
+	resp, err := c.HTTPClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return responseError(resp)
+	}
+
+	var data bytes.Buffer
+	scanner := bufio.NewScanner(resp.Body)
+	for scanner.Scan() {
+		line := scanner.Text()
+		switch {
+		case line == "":
+			if data.Len() == 0 {
+				continue
+			}
+			var event Event
+			if err := json.Unmarshal(data.Bytes(), &event); err != nil {
+				return fmt.Errorf("parsing event: %w", err)
+			}
+			data.Reset()
+			handle(event)
+		case strings.HasPrefix(line, "data:"):
+			if data.Len() > 0 {
+				data.WriteByte('\n')
+			}
+			data.WriteString(strings.TrimPrefix(strings.TrimPrefix(line, "data:"), " "))
+		}
+	}
+
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+	if err := scanner.Err(); err != nil {
+		return err
+	}
+	return io.EOF
+}
+
+func (c *Client) authorize(req *http.Request) {
+	if c.Token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.Token)
+	}
+}
+
+// StatusError is returned when the daemon answers with an error status.
+type StatusError struct {
+	StatusCode int
+	Message    string
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("%d %s: %s", e.StatusCode, http.StatusText(e.StatusCode), e.Message)
+}
+
+func responseError(resp *http.Response) error {
+	statusErr := &StatusError{StatusCode: resp.StatusCode, Message: "unexpected status"}
+
+	var apiErr Error
+	if err := json.NewDecoder(resp.Body).Decode(&apiErr); err == nil && apiErr.Error != "" {
+		statusErr.Message = apiErr.Error
+	}
+	return statusErr
+}
@@ -0,0 +1,86 @@
+// Package api defines the types of the keylightctl daemon's HTTP API.
+package api
+
+import "time"
+
+// State is the state of a light. Temperature is in Kelvin.
+type State struct {
+	On          bool `json:"on"`
+	Brightness  int  `json:"brightness"`
+	Temperature int  `json:"temperature"`
+	// Mired is the exact temperature as reported by the light, which
+	// Temperature only approximates in steps of 50K.
+	Mired int `json:"mired,omitempty"`
+}
+
+type Light struct {
+	Name      string    `json:"name"`
+	IP        string    `json:"ip"`
+	Tags      []string  `json:"tags,omitempty"`
+	Online    bool      `json:"online"`
+	State     *State    `json:"state,omitempty"`
+	Error     string    `json:"error,omitempty"`
+	UpdatedAt time.Time `json:"updatedAt,omitzero"`
+}
+
+// StatePatch changes the state of a light. Nil fields are left unchanged.
+type StatePatch struct {
+	On          *bool `json:"on,omitempty"`
+	Brightness  *int  `json:"brightness,omitempty"`
+	Temperature *int  `json:"temperature,omitempty"`
+	// Mired sets the temperature exactly, instead of Temperature.
+	Mired *int `json:"mired,omitempty"`
+}
+
+type Group struct {
+	Name   string   `json:"name"`
+	Lights []string `json:"lights"`
+	Groups []string `json:"groups,omitempty"`
+}
+
+type Scene struct {
+	Name          string       `json:"name"`
+	Description   string       `json:"description,omitempty"`
+	TurnOffOthers bool         `json:"turnOffOthers,omitempty"`
+	Lights        []SceneLight `json:"lights"`
+}
+
+type SceneLight struct {
+	Light string `json:"light,omitempty"`
+	Group string `json:"group,omitempty"`
+	StatePatch
+}
+
+type ApplySceneRequest struct {
+	// Fade is a duration such as "2s".
+	Fade string `json:"fade,omitempty"`
+}
+
+type ToggleRequest struct {
+	// Mode is "any-on-turns-all-off" (the default) or "independent".
+	Mode string `json:"mode,omitempty"`
+}
+
+type Error struct {
+	Error string `json:"error"`
+}
+
+type EventType string
+
+const (
+	EventStateChanged EventType = "state-changed"
+	EventOnline       EventType = "online"
+	EventOffline      EventType = "offline"
+	EventSceneApplied EventType = "scene-applied"
+)
+
+// Event is pushed by the daemon over Server-Sent Events and WebSocket.
+type Event struct {
+	Type     EventType `json:"type"`
+	Time     time.Time `json:"time"`
+	Light    string    `json:"light,omitempty"`
+	Scene    string    `json:"scene,omitempty"`
+	State    *State    `json:"state,omitempty"`
+	Previous *State    `json:"previous,omitempty"`
+	Error    string    `json:"error,omitempty"`
+}
@@ -0,0 +1,74 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/eckertalex/keylightctl/internal/alarm"
+	"github.com/eckertalex/keylightctl/internal/keylight"
+	"github.com/eckertalex/keylightctl/internal/scene"
+	"github.com/spf13/cobra"
+)
+
+var (
+	alarmSelector    lightSelector
+	alarmDuration    time.Duration
+	alarmBrightness  int
+	alarmTemperature int
+	alarmCmd         = &cobra.Command{
+		Use:   "alarm <HH:MM>",
+		Short: "Wake up with a sunrise of the lights ending at the given time",
+		Args:  cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			end, err := alarm.Next(args[0], time.Now())
+			if err != nil {
+				fmt.Println(err)
+				return
+			}
+			if alarmDuration <= 0 {
+				fmt.Println("Invalid duration: must be positive")
+				return
+			}
+			if err := keylight.ValidateBrightness(alarmBrightness); err != nil {
+				fmt.Printf("Invalid brightness: %v\n", err)
+				return
+			}
+			if err := keylight.ValidateTemperature(alarmTemperature); err != nil {
+				fmt.Printf("Invalid temperature: %v\n", err)
+				return
+			}
+
+			lightConfigs, err := alarmSelector.resolve(lightsConfig, groupsConfig)
+			if err != nil {
+				fmt.Println(err)
+				return
+			}
+
+			state := scene.State{Brightness: &alarmBrightness, Temperature: &alarmTemperature}
+			targets := make([]scene.Target, len(lightConfigs))
+			for i, light := range lightConfigs {
+				targets[i] = scene.Target{Light: light.Light, State: state}
+			}
+
+			sunrise := alarm.NewSunrise(lightClient(), targets, end, alarmDuration)
+			fmt.Printf("Sunrise from %s to %s, press Ctrl+C to cancel\n", sunrise.Start().Format("Mon 15:04"), end.Format("Mon 15:04"))
+
+			ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+			defer stop()
+			sunrise.Run(ctx)
+		},
+	}
+)
+
+func init() {
+	addSelectorFlags(alarmCmd, &alarmSelector)
+	alarmCmd.Flags().DurationVar(&alarmDuration, "duration", alarm.DefaultDuration, "Duration of the sunrise, ending at the alarm time")
+	alarmCmd.Flags().IntVarP(&alarmBrightness, "brightness", "b", alarm.DefaultBrightness, "Brightness percentage (0-100) to end at")
+	alarmCmd.Flags().IntVarP(&alarmTemperature, "temperature", "t", alarm.DefaultTemperature, "Temperature in Kelvin (2900-7000) to end at")
+
+	rootCmd.AddCommand(alarmCmd)
+}
@@ -0,0 +1,98 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"syscall"
+	"time"
+
+	"github.com/eckertalex/keylightctl/internal/camera"
+	"github.com/spf13/cobra"
+)
+
+var (
+	autoSelector lightSelector
+	autoCamera   bool
+	autoGrace    time.Duration
+	autoInterval time.Duration
+	autoCmd      = &cobra.Command{
+		Use:   "auto",
+		Short: "Turn the lights on automatically while they are needed",
+		Args:  cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			if !autoCamera {
+				fmt.Println("Nothing to do, pass --camera to turn the lights on while a camera is in use")
+				return
+			}
+
+			config := autoConfig
+			if cmd.Flags().Changed("grace") {
+				config.Grace = autoGrace
+			}
+			if cmd.Flags().Changed("interval") {
+				config.Interval = autoInterval
+			}
+			if err := camera.Validate(&config); err != nil {
+				fmt.Println(err)
+				return
+			}
+
+			cameras, err := autoCameras(config)
+			if err != nil {
+				fmt.Println(err)
+				return
+			}
+
+			ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+			defer stop()
+
+			runner := camera.NewRunner(freshClient(), cameras, config.Grace, config.Interval)
+			if err := runner.Run(ctx); err != nil {
+				fmt.Println(err)
+			}
+		},
+	}
+)
+
+// autoCameras returns the configured camera mappings, or a single mapping of
+// every camera to the selected lights if lights are selected on the command
+// line or no cameras are configured.
+func autoCameras(config camera.Config) ([]camera.Camera, error) {
+	if !autoSelector.empty() || len(config.Cameras) == 0 {
+		lightConfigs, err := autoSelector.resolve(lightsConfig, groupsConfig)
+		if err != nil {
+			return nil, err
+		}
+		return []camera.Camera{{Lights: ToLights(lightConfigs)}}, nil
+	}
+
+	cameras := make([]camera.Camera, 0, len(config.Cameras))
+	for _, cfg := range config.Cameras {
+		selector := lightSelector{names: cfg.Lights, groups: cfg.Groups}
+		lightConfigs, err := selector.resolve(lightsConfig, groupsConfig)
+		if err != nil {
+			return nil, fmt.Errorf("camera '%s': %w", cfg.Device, err)
+		}
+
+		// Processes hold the device itself, not a stable alias such as
+		// /dev/v4l/by-id/...
+		device := cfg.Device
+		if resolved, err := filepath.EvalSymlinks(device); err == nil {
+			device = resolved
+		}
+		cameras = append(cameras, camera.Camera{Device: device, Lights: ToLights(lightConfigs)})
+	}
+	return cameras, nil
+}
+
+func init() {
+	addSelectorFlags(autoCmd, &autoSelector)
+	autoCmd.Flags().BoolVar(&autoCamera, "camera", false, "Turn the lights on while a camera (/dev/video*) is in use (Linux)")
+	autoCmd.Flags().DurationVar(&autoGrace, "grace", camera.DefaultGrace, "How long a camera must be released before the lights are restored")
+	autoCmd.Flags().DurationVar(&autoInterval, "interval", camera.DefaultInterval, "How often to check whether a camera is in use")
+
+	rootCmd.AddCommand(autoCmd)
+}
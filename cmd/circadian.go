@@ -0,0 +1,99 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/eckertalex/keylightctl/internal/circadian"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+var (
+	circadianSelector         lightSelector
+	circadianLatitude         float64
+	circadianLongitude        float64
+	circadianDayTemperature   int
+	circadianNightTemperature int
+	circadianInterval         time.Duration
+	circadianPause            time.Duration
+	circadianCmd              = &cobra.Command{
+		Use:   "circadian",
+		Short: "Set the color temperature of the lights along the course of the sun",
+		Args:  cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			config := circadianConfig
+			flags := cmd.Flags()
+			if flags.Changed("lat") {
+				config.Latitude = circadianLatitude
+			}
+			if flags.Changed("lon") {
+				config.Longitude = circadianLongitude
+			}
+			if flags.Changed("day-temperature") {
+				config.DayTemperature = circadianDayTemperature
+			}
+			if flags.Changed("night-temperature") {
+				config.NightTemperature = circadianNightTemperature
+			}
+			if flags.Changed("interval") {
+				config.Interval = circadianInterval
+			}
+			if flags.Changed("pause") {
+				config.Pause = circadianPause
+			}
+
+			if !(flags.Changed("lat") || viper.IsSet("circadian.latitude")) ||
+				!(flags.Changed("lon") || viper.IsSet("circadian.longitude")) {
+				fmt.Println("Latitude and longitude are required, set --lat and --lon or configure them in the circadian block")
+				return
+			}
+			if err := circadian.Validate(&config); err != nil {
+				fmt.Println(err)
+				return
+			}
+
+			selector := circadianSelector
+			if selector.empty() {
+				selector = lightSelector{names: config.Lights, groups: config.Groups}
+			}
+			lightConfigs, err := selector.resolve(lightsConfig, groupsConfig)
+			if err != nil {
+				fmt.Println(err)
+				return
+			}
+
+			runner := circadian.NewRunner(lightClient(), ToLights(lightConfigs), config)
+
+			now := time.Now()
+			day := circadian.SunDay(now, config.Latitude, config.Longitude)
+			if day.Sunrise.IsZero() {
+				fmt.Printf("No sunrise or sunset today, solar noon at %s\n", day.Noon.Format("15:04"))
+			} else {
+				fmt.Printf("Sunrise at %s, solar noon at %s, sunset at %s\n", day.Sunrise.Format("15:04"), day.Noon.Format("15:04"), day.Sunset.Format("15:04"))
+			}
+			fmt.Printf("Current temperature: %dK\n", runner.Target(now))
+
+			ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+			defer stop()
+
+			runner.Run(ctx)
+		},
+	}
+)
+
+func init() {
+	addSelectorFlags(circadianCmd, &circadianSelector)
+	circadianCmd.Flags().Float64Var(&circadianLatitude, "lat", 0, "Latitude in degrees, north is positive")
+	circadianCmd.Flags().Float64Var(&circadianLongitude, "lon", 0, "Longitude in degrees, east is positive")
+	circadianCmd.Flags().IntVar(&circadianDayTemperature, "day-temperature", circadian.DefaultDayTemperature, "Temperature at solar noon in Kelvin")
+	circadianCmd.Flags().IntVar(&circadianNightTemperature, "night-temperature", circadian.DefaultNightTemperature, "Temperature after dusk in Kelvin")
+	circadianCmd.Flags().DurationVar(&circadianInterval, "interval", circadian.DefaultInterval, "How often the temperature is updated")
+	circadianCmd.Flags().DurationVar(&circadianPause, "pause", circadian.DefaultPause, "How long to leave a manually adjusted light alone")
+
+	rootCmd.AddCommand(circadianCmd)
+}
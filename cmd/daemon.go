@@ -0,0 +1,149 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"net"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/eckertalex/keylightctl/api"
+	"github.com/eckertalex/keylightctl/internal/keylight"
+)
+
+// daemonTimeout bounds every request to the daemon, so that a hung daemon
+// does not hang the CLI.
+const daemonTimeout = 10 * time.Second
+
+var (
+	forceDirect  bool
+	remoteClient *api.Client
+
+	lightClientOnce sync.Once
+	sharedClient    keylight.Client
+)
+
+// lightClient returns the client used to talk to the lights: the remote
+// daemon in remote mode, a local daemon if one is listening on its socket, or
+// the lights themselves.
+func lightClient() keylight.Client {
+	lightClientOnce.Do(func() {
+		if remoteClient != nil {
+			sharedClient = newDaemonClient(remoteClient, lightsConfig)
+			return
+		}
+
+		sharedClient = keylight.NewController()
+		if forceDirect {
+			return
+		}
+
+		socket := api.DefaultSocketPath()
+		if !daemonListening(socket) {
+			return
+		}
+		sharedClient = newDaemonClient(api.NewSocketClient(socket), lightsConfig)
+	})
+	return sharedClient
+}
+
+// freshClient is like lightClient, but reads through a daemon bypass its
+// cache, which lags behind changes made by other apps. Commands that capture
+// the state of lights to restore it later use it.
+func freshClient() keylight.Client {
+	if d, ok := lightClient().(*daemonClient); ok {
+		return &daemonClient{client: d.client, names: d.names, refresh: true}
+	}
+	return lightClient()
+}
+
+func daemonListening(socket string) bool {
+	if _, err := os.Stat(socket); err != nil {
+		return false
+	}
+
+	conn, err := net.DialTimeout("unix", socket, 200*time.Millisecond)
+	if err != nil {
+		return false
+	}
+	conn.Close()
+	return true
+}
+
+// daemonError is a light error reported by the daemon.
+type daemonError struct {
+	msg string
+}
+
+func (e *daemonError) Error() string {
+	return e.msg
+}
+
+// daemonClient implements keylight.Client on top of the daemon's API, which
+// addresses lights by name rather than by address. Reads are answered from
+// the daemon's cache unless refresh is set.
+type daemonClient struct {
+	client  *api.Client
+	names   map[string]string
+	refresh bool
+}
+
+func newDaemonClient(client *api.Client, lights []keylight.LightConfig) *daemonClient {
+	names := make(map[string]string, len(lights))
+	for _, light := range lights {
+		names[light.IP] = light.Name
+	}
+	return &daemonClient{client: client, names: names}
+}
+
+func (d *daemonClient) GetLight(ip string) (*keylight.LightStatus, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), daemonTimeout)
+	defer cancel()
+
+	read := d.client.Light
+	if d.refresh {
+		read = d.client.RefreshLight
+	}
+	light, err := read(ctx, d.names[ip])
+	if err != nil {
+		return nil, err
+	}
+	return toLightStatus(light)
+}
+
+func (d *daemonClient) UpdateLight(ip string, settings keylight.LightDetail) (*keylight.LightStatus, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), daemonTimeout)
+	defer cancel()
+
+	on := settings.On == 1
+	patch := api.StatePatch{On: &on}
+	if settings.Brightness != 0 {
+		patch.Brightness = &settings.Brightness
+	}
+	if settings.Temperature != 0 {
+		patch.Mired = &settings.Temperature
+	}
+
+	light, err := d.client.PatchLight(ctx, d.names[ip], patch)
+	if err != nil {
+		return nil, err
+	}
+	return toLightStatus(light)
+}
+
+func toLightStatus(light *api.Light) (*keylight.LightStatus, error) {
+	if !light.Online || light.State == nil {
+		if light.Error == "" {
+			return nil, errors.New("light is offline")
+		}
+		return nil, &daemonError{msg: light.Error}
+	}
+
+	detail := keylight.LightDetail{Brightness: light.State.Brightness}
+	if light.State.On {
+		detail.On = 1
+	}
+	detail.Temperature = light.State.Mired
+	return &keylight.LightStatus{Lights: []keylight.LightDetail{detail}, NumberOfLights: 1}, nil
+}
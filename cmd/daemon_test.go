@@ -0,0 +1,82 @@
+package cmd
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"github.com/eckertalex/keylightctl/api"
+	"github.com/eckertalex/keylightctl/internal/keylight"
+	"github.com/eckertalex/keylightctl/internal/keylighttest"
+	"github.com/eckertalex/keylightctl/internal/server"
+)
+
+func TestDaemonClientKeepsExactMired(t *testing.T) {
+	lights := []keylight.LightConfig{{Light: keylight.Light{Name: "Left", IP: "10.0.0.1"}}}
+	fake := keylighttest.NewLights(map[string]keylight.LightDetail{"10.0.0.1": {On: 1, Brightness: 20, Temperature: 200}})
+	srv := httptest.NewServer(server.New(server.Config{Lights: lights}, fake).Handler())
+	defer srv.Close()
+
+	client := newDaemonClient(api.NewClient(srv.URL), lights)
+	for mired := keylight.MinMired; mired <= keylight.MaxMired; mired++ {
+		status, err := client.UpdateLight("10.0.0.1", keylight.LightDetail{On: 1, Brightness: 40, Temperature: mired})
+		if err != nil {
+			t.Fatalf("UpdateLight(%d): %v", mired, err)
+		}
+		if got := status.Lights[0].Temperature; got != mired {
+			t.Errorf("UpdateLight(%d) reported %d mired", mired, got)
+		}
+		if got := fake.Get("10.0.0.1").Temperature; got != mired {
+			t.Errorf("UpdateLight(%d) sent %d mired to the light", mired, got)
+		}
+
+		status, err = client.GetLight("10.0.0.1")
+		if err != nil {
+			t.Fatalf("GetLight: %v", err)
+		}
+		if got := status.Lights[0].Temperature; got != mired {
+			t.Errorf("GetLight after setting %d mired reported %d", mired, got)
+		}
+	}
+}
+
+func TestDaemonClientRefresh(t *testing.T) {
+	lights := []keylight.LightConfig{{Light: keylight.Light{Name: "Left", IP: "10.0.0.1"}}}
+	fake := keylighttest.NewLights(map[string]keylight.LightDetail{"10.0.0.1": {On: 1, Brightness: 20, Temperature: 200}})
+	srv := httptest.NewServer(server.New(server.Config{Lights: lights}, fake).Handler())
+	defer srv.Close()
+
+	client := newDaemonClient(api.NewClient(srv.URL), lights)
+	if _, err := client.UpdateLight("10.0.0.1", keylight.LightDetail{On: 1, Brightness: 40, Temperature: 200}); err != nil {
+		t.Fatal(err)
+	}
+
+	// Another app changes the light before the daemon polls it.
+	changed := keylight.LightDetail{On: 0, Brightness: 70, Temperature: 300}
+	fake.Set("10.0.0.1", changed)
+
+	status, err := client.GetLight("10.0.0.1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := status.Lights[0]; got == changed {
+		t.Errorf("cached read already reports %+v", got)
+	}
+
+	fresh := &daemonClient{client: client.client, names: client.names, refresh: true}
+	status, err = fresh.GetLight("10.0.0.1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := status.Lights[0]; got != changed {
+		t.Errorf("fresh read reported %+v, want %+v", got, changed)
+	}
+
+	// The fresh read updates the cache for everyone.
+	status, err = client.GetLight("10.0.0.1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := status.Lights[0]; got != changed {
+		t.Errorf("cached read after a fresh one reported %+v, want %+v", got, changed)
+	}
+}
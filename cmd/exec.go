@@ -0,0 +1,230 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/eckertalex/keylightctl/internal/keylight"
+	"github.com/eckertalex/keylightctl/internal/scene"
+	"github.com/spf13/cobra"
+)
+
+var (
+	execSelector    lightSelector
+	execScene       string
+	execBrightness  int
+	execTemperature int
+	execFade        time.Duration
+	execCmd         = &cobra.Command{
+		Use:   "exec [flags] -- <command> [args...]",
+		Short: "Light up while running a command, then restore the lights",
+		Long: `Apply a scene, or turn on the selected lights, run a command and restore the
+exact previous state of the lights when it exits. Termination and hangup
+signals are forwarded to the command, which receives interrupts from the
+terminal directly, and its exit code is propagated.`,
+		Args: cobra.MinimumNArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			targets, err := execTargets(cmd)
+			if err != nil {
+				fmt.Fprintln(os.Stderr, err)
+				os.Exit(1)
+			}
+
+			// Signals are caught until the process exits, so that none can
+			// interrupt the restore.
+			signals := make(chan os.Signal, 1)
+			signal.Notify(signals, execSignals...)
+			ctx, stop := signal.NotifyContext(context.Background(), execSignals...)
+
+			client := freshClient()
+			previous, err := captureTargets(client, targets)
+			if err != nil {
+				fmt.Fprintln(os.Stderr, err)
+				os.Exit(1)
+			}
+
+			states := make(map[string]scene.State, len(targets))
+			for _, target := range targets {
+				states[target.Light.IP] = target.State
+			}
+			reportLightErrors("Update", collectLightResults(targetLights(targets), func(ip string) (*keylight.LightStatus, error) {
+				status, err := scene.ApplyState(ctx, client, ip, states[ip], execFade)
+				if ctx.Err() != nil {
+					// The fade was interrupted, the lights are restored below.
+					return status, nil
+				}
+				return status, err
+			}))
+			stop()
+
+			var code int
+			select {
+			case sig := <-signals:
+				// Interrupted before the command started.
+				code = signalExitCode(sig)
+			default:
+				code = runForwardingSignals(args, signals)
+			}
+
+			reportLightErrors("Restore", collectLightResults(targetLights(targets), func(ip string) (*keylight.LightStatus, error) {
+				return client.UpdateLight(ip, previous[ip])
+			}))
+			os.Exit(code)
+		},
+	}
+)
+
+var execSignals = []os.Signal{os.Interrupt, syscall.SIGTERM, syscall.SIGHUP, syscall.SIGQUIT}
+
+// execTargets returns the targets of the scene, or turns the selected lights
+// on with the given brightness and temperature.
+func execTargets(cmd *cobra.Command) ([]scene.Target, error) {
+	if execScene != "" {
+		s, err := findScene(execScene)
+		if err != nil {
+			return nil, err
+		}
+		return scene.Resolve(*s, lightsConfig, groupsConfig)
+	}
+
+	on := true
+	state := scene.State{On: &on}
+	if cmd.Flags().Changed("brightness") {
+		if err := keylight.ValidateBrightness(execBrightness); err != nil {
+			return nil, fmt.Errorf("invalid brightness: %w", err)
+		}
+		state.Brightness = &execBrightness
+	}
+	if cmd.Flags().Changed("temperature") {
+		if err := keylight.ValidateTemperature(execTemperature); err != nil {
+			return nil, fmt.Errorf("invalid temperature: %w", err)
+		}
+		state.Temperature = &execTemperature
+	}
+
+	lightConfigs, err := execSelector.resolve(lightsConfig, groupsConfig)
+	if err != nil {
+		return nil, err
+	}
+	targets := make([]scene.Target, 0, len(lightConfigs))
+	for _, cfg := range lightConfigs {
+		targets = append(targets, scene.Target{Light: cfg.Light, State: state})
+	}
+	return targets, nil
+}
+
+// captureTargets returns the current state of every target light, keyed by
+// IP.
+func captureTargets(client keylight.Client, targets []scene.Target) (map[string]keylight.LightDetail, error) {
+	lights := targetLights(targets)
+	previous := make(map[string]keylight.LightDetail, len(targets))
+	var errs []error
+	for i, result := range collectLightResults(lights, client.GetLight) {
+		switch {
+		case result.err != nil:
+			errs = append(errs, fmt.Errorf("light '%s': %s", result.name, describeError(result.err)))
+		case len(result.status.Lights) == 0:
+			errs = append(errs, fmt.Errorf("light '%s': empty status", result.name))
+		default:
+			previous[lights[i].IP] = result.status.Lights[0]
+		}
+	}
+	if len(errs) > 0 {
+		return nil, fmt.Errorf("cannot capture the state to restore: %w", errors.Join(errs...))
+	}
+	return previous, nil
+}
+
+func targetLights(targets []scene.Target) []keylight.Light {
+	lights := make([]keylight.Light, 0, len(targets))
+	for _, target := range targets {
+		lights = append(lights, target.Light)
+	}
+	return lights
+}
+
+// reportLightErrors prints the failures among results to standard error, to
+// keep standard output to the command.
+func reportLightErrors(operationName string, results []lightResult) {
+	for _, result := range results {
+		if result.err != nil {
+			fmt.Fprintf(os.Stderr, "%s of light \"%s\": Error: %s\n", operationName, result.name, describeError(result.err))
+		}
+	}
+}
+
+// runForwardingSignals runs a command, forwarding the signals received to it,
+// and returns its exit code. Interrupt and quit signals are not forwarded, as
+// the terminal already sends them to the command. A command killed by a
+// signal exits with 128 plus the signal number, like in a shell.
+func runForwardingSignals(args []string, signals <-chan os.Signal) int {
+	child := exec.Command(args[0], args[1:]...)
+	child.Stdin = os.Stdin
+	child.Stdout = os.Stdout
+	child.Stderr = os.Stderr
+
+	if err := child.Start(); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 127
+	}
+
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		for {
+			select {
+			case sig := <-signals:
+				if sig != os.Interrupt && sig != syscall.SIGQUIT {
+					child.Process.Signal(sig)
+				}
+			case <-done:
+				return
+			}
+		}
+	}()
+
+	err := child.Wait()
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		if status, ok := exitErr.Sys().(syscall.WaitStatus); ok && status.Signaled() {
+			return signalExitCode(status.Signal())
+		}
+		return exitErr.ExitCode()
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	return 0
+}
+
+// signalExitCode returns the exit code of a process killed by sig.
+func signalExitCode(sig os.Signal) int {
+	if s, ok := sig.(syscall.Signal); ok {
+		return 128 + int(s)
+	}
+	return 1
+}
+
+func init() {
+	addSelectorFlags(execCmd, &execSelector)
+	execCmd.Flags().StringVarP(&execScene, "scene", "s", "", "Scene to apply while the command runs")
+	execCmd.Flags().IntVarP(&execBrightness, "brightness", "b", 0, "Brightness percentage (0-100)")
+	execCmd.Flags().IntVarP(&execTemperature, "temperature", "t", 0, "Color temperature in Kelvin (2900-7000)")
+	execCmd.Flags().DurationVar(&execFade, "fade", 0, "Fade to the scene or state over this duration (e.g. 2s)")
+	execCmd.MarkFlagsMutuallyExclusive("scene", "light")
+	execCmd.MarkFlagsMutuallyExclusive("scene", "tag")
+	execCmd.MarkFlagsMutuallyExclusive("scene", "group")
+	execCmd.MarkFlagsMutuallyExclusive("scene", "brightness")
+	execCmd.MarkFlagsMutuallyExclusive("scene", "temperature")
+	// Flags after the command belong to the command.
+	execCmd.Flags().SetInterspersed(false)
+
+	rootCmd.AddCommand(execCmd)
+}
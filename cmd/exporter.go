@@ -0,0 +1,95 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/eckertalex/keylightctl/internal/keylight"
+	"github.com/eckertalex/keylightctl/internal/metrics"
+	"github.com/eckertalex/keylightctl/internal/watch"
+	"github.com/spf13/cobra"
+)
+
+var (
+	exporterSelector lightSelector
+	exporterListen   string
+	exporterInterval time.Duration
+	exporterCmd      = &cobra.Command{
+		Use:   "exporter",
+		Short: "Expose the lights as Prometheus metrics",
+		Long: `Poll the lights and expose their state, together with request latencies,
+errors and retries, as Prometheus metrics on /metrics.
+
+The exporter always talks to the lights directly. To export the metrics of a
+running daemon, use serve --metrics instead.`,
+		Run: func(cmd *cobra.Command, args []string) {
+			if remoteURL() != "" {
+				fmt.Println("exporter cannot run in remote mode, use serve --metrics on the remote host")
+				return
+			}
+
+			if exporterInterval <= 0 {
+				fmt.Println("Invalid interval: must be positive")
+				return
+			}
+
+			lightConfigs, err := exporterSelector.resolve(lightsConfig, groupsConfig)
+			if err != nil {
+				fmt.Println(err)
+				return
+			}
+			lights := ToLights(lightConfigs)
+
+			ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+			defer stop()
+
+			m := metrics.New(lights)
+			watcher := watch.New(keylight.NewController(keylight.WithObserver(m)), lights, exporterInterval)
+			go watcher.Run(ctx, func(event watch.Event) {
+				if event.Type == watch.EventOffline {
+					m.SetOffline(event.Light.Name)
+					return
+				}
+				m.SetState(event.Light.Name, event.Current)
+			})
+
+			mux := http.NewServeMux()
+			mux.Handle("GET /metrics", m.Handler())
+
+			httpServer := &http.Server{
+				Addr:              exporterListen,
+				Handler:           mux,
+				ReadHeaderTimeout: 10 * time.Second,
+				BaseContext:       func(net.Listener) context.Context { return ctx },
+			}
+
+			go func() {
+				<-ctx.Done()
+				shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+				defer cancel()
+				httpServer.Shutdown(shutdownCtx)
+			}()
+
+			log.Printf("Serving metrics on http://%s/metrics", exporterListen)
+			if err := httpServer.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+				log.Fatalf("Server failed: %v", err)
+			}
+		},
+	}
+)
+
+func init() {
+	addSelectorFlags(exporterCmd, &exporterSelector)
+	exporterCmd.Flags().StringVar(&exporterListen, "listen", ":9814", "Address to serve metrics on")
+	exporterCmd.Flags().DurationVar(&exporterInterval, "interval", 15*time.Second, "Polling interval")
+
+	rootCmd.AddCommand(exporterCmd)
+}
@@ -0,0 +1,123 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"slices"
+	"syscall"
+	"time"
+
+	"github.com/eckertalex/keylightctl/internal/follow"
+	"github.com/eckertalex/keylightctl/internal/keylight"
+	"github.com/spf13/cobra"
+)
+
+var (
+	followLeader            string
+	followFollowers         []string
+	followBrightnessOffset  int
+	followTemperatureOffset int
+	followInterval          time.Duration
+	followCmd               = &cobra.Command{
+		Use:   "follow",
+		Short: "Mirror changes of a leader light to follower lights",
+		Long: `Mirror changes of a leader light, made from any app, to follower lights.
+
+Without --leader, every light configured with a follows key mirrors the light it follows.`,
+		Args: cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			if followInterval <= 0 {
+				fmt.Println("Invalid interval: must be positive")
+				return
+			}
+
+			followers, err := followersFromFlags()
+			if err != nil {
+				fmt.Println(err)
+				return
+			}
+			if len(followers) == 0 {
+				fmt.Println("No followers configured, set --leader and --followers or configure follows on the lights")
+				return
+			}
+
+			var leaders []keylight.Light
+			for _, light := range lightsConfig {
+				if fs, ok := followers[light.Name]; ok {
+					leaders = append(leaders, light.Light)
+					for _, f := range fs {
+						fmt.Printf("%s follows %s\n", f.Light.Name, light.Name)
+					}
+				}
+			}
+
+			ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+			defer stop()
+
+			follow.NewRunner(lightClient(), leaders, followers, followInterval).Run(ctx)
+		},
+	}
+)
+
+// followersFromFlags returns the followers by leader name given on the
+// command line, or configured through the follows key without --leader.
+func followersFromFlags() (map[string][]follow.Follower, error) {
+	if followLeader == "" {
+		if len(followFollowers) > 0 {
+			return nil, fmt.Errorf("--followers requires --leader")
+		}
+		if remoteURL() != "" {
+			return nil, fmt.Errorf("follows keys are mirrored by the daemon on the remote host, use --leader and --followers to mirror lights from here")
+		}
+		return follow.Followers(lightsConfig), nil
+	}
+
+	if findLightConfig(followLeader) == nil {
+		return nil, fmt.Errorf("light '%s' not found", followLeader)
+	}
+	if len(followFollowers) == 0 {
+		return nil, fmt.Errorf("--leader requires --followers")
+	}
+
+	var followers []follow.Follower
+	for _, name := range followFollowers {
+		// A light following itself would propagate its changes in a loop.
+		if name == followLeader {
+			return nil, fmt.Errorf("light '%s' cannot follow itself", name)
+		}
+		light := findLightConfig(name)
+		if light == nil {
+			return nil, fmt.Errorf("light '%s' not found", name)
+		}
+		if slices.ContainsFunc(followers, func(f follow.Follower) bool { return f.Light.Name == name }) {
+			continue
+		}
+		followers = append(followers, follow.Follower{
+			Light:             light.Light,
+			BrightnessOffset:  followBrightnessOffset,
+			TemperatureOffset: followTemperatureOffset,
+		})
+	}
+	return map[string][]follow.Follower{followLeader: followers}, nil
+}
+
+func findLightConfig(name string) *keylight.LightConfig {
+	for i := range lightsConfig {
+		if lightsConfig[i].Name == name {
+			return &lightsConfig[i]
+		}
+	}
+	return nil
+}
+
+func init() {
+	followCmd.Flags().StringVar(&followLeader, "leader", "", "Name of the light to mirror")
+	followCmd.Flags().StringSliceVar(&followFollowers, "followers", nil, "Names of the lights mirroring the leader (comma-separated)")
+	followCmd.Flags().IntVar(&followBrightnessOffset, "brightness-offset", 0, "Percentage added to the brightness of the leader")
+	followCmd.Flags().IntVar(&followTemperatureOffset, "temperature-offset", 0, "Kelvin added to the temperature of the leader")
+	followCmd.Flags().DurationVar(&followInterval, "interval", time.Second, "Interval at which the leaders are polled")
+
+	rootCmd.AddCommand(followCmd)
+}
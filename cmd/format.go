@@ -0,0 +1,149 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"text/template"
+
+	"github.com/eckertalex/keylightctl/internal/keylight"
+)
+
+const waybarFormat = "waybar"
+
+// kelvin converts a temperature reported by a light to Kelvin. Lights may
+// report 0, which is kept rather than divided by.
+func kelvin(mired int) int {
+	if mired == 0 {
+		return 0
+	}
+	return keylight.MiredToKelvin(mired)
+}
+
+// lightView is the data passed to --format templates, one per light.
+type lightView struct {
+	Name        string
+	IP          string
+	On          bool
+	Brightness  int
+	Temperature int
+	Error       string
+}
+
+var formatFuncs = template.FuncMap{
+	"kelvin":  kelvin,
+	"mired":   keylight.KelvinToMired,
+	"percent": func(n int) string { return fmt.Sprintf("%d%%", n) },
+	"onoff": func(on bool) string {
+		if on {
+			return formatOnOff(1)
+		}
+		return formatOnOff(0)
+	},
+}
+
+func toLightViews(lights []keylight.Light, results []lightResult) []lightView {
+	views := make([]lightView, len(lights))
+	for i, light := range lights {
+		views[i] = lightView{Name: light.Name, IP: light.IP}
+
+		result := results[i]
+		switch {
+		case result.err != nil:
+			views[i].Error = describeError(result.err)
+		case result.status == nil || len(result.status.Lights) == 0:
+			views[i].Error = "empty status"
+		default:
+			detail := result.status.Lights[0]
+			views[i].On = detail.On == 1
+			views[i].Brightness = detail.Brightness
+			views[i].Temperature = detail.Temperature
+		}
+	}
+	return views
+}
+
+func parseFormat(format string) (*template.Template, error) {
+	tmpl, err := template.New("format").Funcs(formatFuncs).Parse(format)
+	if err != nil {
+		return nil, fmt.Errorf("parsing format: %w", err)
+	}
+	return tmpl, nil
+}
+
+func writeTemplate(w io.Writer, tmpl *template.Template, views []lightView) error {
+	for _, view := range views {
+		if err := tmpl.Execute(w, view); err != nil {
+			return fmt.Errorf("executing format: %w", err)
+		}
+		fmt.Fprintln(w)
+	}
+	return nil
+}
+
+type waybarOutput struct {
+	Text       string `json:"text"`
+	Alt        string `json:"alt"`
+	Tooltip    string `json:"tooltip"`
+	Class      string `json:"class"`
+	Percentage int    `json:"percentage"`
+}
+
+// writeWaybar emits a single JSON object summarizing all lights, as expected
+// by a waybar custom module with "return-type": "json".
+func writeWaybar(w io.Writer, views []lightView) error {
+	var (
+		tooltip []string
+		on      int
+		total   int
+	)
+
+	for _, view := range views {
+		if view.Error != "" {
+			tooltip = append(tooltip, fmt.Sprintf("%s: %s", view.Name, view.Error))
+			continue
+		}
+		if !view.On {
+			tooltip = append(tooltip, fmt.Sprintf("%s: %s", view.Name, formatOnOff(0)))
+			continue
+		}
+		on++
+		total += view.Brightness
+		tooltip = append(tooltip, fmt.Sprintf("%s: %s %d%% %dK",
+			view.Name, formatOnOff(1), view.Brightness, kelvin(view.Temperature)))
+	}
+
+	out := waybarOutput{
+		Text:    "off",
+		Alt:     "off",
+		Tooltip: strings.Join(tooltip, "\n"),
+		Class:   "off",
+	}
+	if on > 0 {
+		out.Percentage = total / on
+		out.Text = fmt.Sprintf("%d%%", out.Percentage)
+		out.Alt = "on"
+		out.Class = "on"
+	}
+
+	return json.NewEncoder(w).Encode(out)
+}
+
+func formatLightsSettings(w io.Writer, lights []keylight.Light, format string) error {
+	var tmpl *template.Template
+	if format != waybarFormat {
+		var err error
+		if tmpl, err = parseFormat(format); err != nil {
+			return err
+		}
+	}
+
+	controller := lightClient()
+	views := toLightViews(lights, collectLightResults(lights, controller.GetLight))
+
+	if tmpl == nil {
+		return writeWaybar(w, views)
+	}
+	return writeTemplate(w, tmpl, views)
+}
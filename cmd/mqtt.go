@@ -0,0 +1,82 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/eckertalex/keylightctl/internal/mqtt"
+	paho "github.com/eclipse/paho.mqtt.golang"
+	"github.com/spf13/cobra"
+)
+
+var (
+	mqttSelector        lightSelector
+	mqttBroker          string
+	mqttUsername        string
+	mqttPassword        string
+	mqttClientID        string
+	mqttPrefix          string
+	mqttDiscoveryPrefix string
+	mqttInterval        time.Duration
+	mqttCmd             = &cobra.Command{
+		Use:   "mqtt",
+		Short: "Bridge the lights to an MQTT broker with Home Assistant discovery",
+		Run: func(cmd *cobra.Command, args []string) {
+			if mqttInterval <= 0 {
+				fmt.Println("Invalid interval: must be positive")
+				return
+			}
+
+			if mqttPassword == "" {
+				mqttPassword = os.Getenv("KEYLIGHTCTL_MQTT_PASSWORD")
+			}
+
+			lightConfigs, err := mqttSelector.resolve(lightsConfig, groupsConfig)
+			if err != nil {
+				fmt.Println(err)
+				return
+			}
+
+			opts := paho.NewClientOptions().
+				AddBroker(mqttBroker).
+				SetClientID(mqttClientID).
+				SetUsername(mqttUsername).
+				SetPassword(mqttPassword).
+				SetConnectTimeout(10 * time.Second)
+
+			bridge := mqtt.New(mqtt.Config{
+				Lights:          ToLights(lightConfigs),
+				Prefix:          mqttPrefix,
+				DiscoveryPrefix: mqttDiscoveryPrefix,
+				Interval:        mqttInterval,
+			}, opts, lightClient())
+
+			ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+			defer stop()
+
+			if err := bridge.Run(ctx); err != nil {
+				fmt.Printf("MQTT bridge failed: %v\n", err)
+			}
+		},
+	}
+)
+
+func init() {
+	hostname, _ := os.Hostname()
+
+	addSelectorFlags(mqttCmd, &mqttSelector)
+	mqttCmd.Flags().StringVar(&mqttBroker, "broker", "", "MQTT broker URL, e.g. tcp://localhost:1883")
+	mqttCmd.MarkFlagRequired("broker")
+	mqttCmd.Flags().StringVar(&mqttUsername, "username", "", "MQTT username")
+	mqttCmd.Flags().StringVar(&mqttPassword, "password", "", "MQTT password (default $KEYLIGHTCTL_MQTT_PASSWORD)")
+	mqttCmd.Flags().StringVar(&mqttClientID, "client-id", "keylightctl-"+hostname, "MQTT client ID")
+	mqttCmd.Flags().StringVar(&mqttPrefix, "topic-prefix", "keylightctl", "Root of the state, command and availability topics")
+	mqttCmd.Flags().StringVar(&mqttDiscoveryPrefix, "discovery-prefix", "homeassistant", "Root of Home Assistant's discovery topics")
+	mqttCmd.Flags().DurationVar(&mqttInterval, "interval", 2*time.Second, "Polling interval")
+
+	rootCmd.AddCommand(mqttCmd)
+}
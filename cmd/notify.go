@@ -0,0 +1,88 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/eckertalex/keylightctl/internal/effect"
+	"github.com/eckertalex/keylightctl/internal/keylight"
+	"github.com/spf13/cobra"
+)
+
+var (
+	notifySelector   lightSelector
+	notifyEffect     string
+	notifyCount      int
+	notifyPeriod     time.Duration
+	notifyBrightness int
+	notifyCmd        = &cobra.Command{
+		Use:   "notify",
+		Short: "Play a notification effect on the lights and restore them afterwards",
+		Args:  cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			e, err := effect.ParseEffect(notifyEffect)
+			if err != nil {
+				fmt.Println(err)
+				return
+			}
+			if notifyCount <= 0 {
+				fmt.Println("Invalid count: must be positive")
+				return
+			}
+			period := effect.DefaultPeriods[e]
+			if cmd.Flags().Changed("period") {
+				period = notifyPeriod
+			}
+			if period <= 0 {
+				fmt.Println("Invalid period: must be positive")
+				return
+			}
+			if err := keylight.ValidateBrightness(notifyBrightness); err != nil {
+				fmt.Printf("Invalid brightness: %v\n", err)
+				return
+			}
+
+			lightConfigs, err := notifySelector.resolve(lightsConfig, groupsConfig)
+			if err != nil {
+				fmt.Println(err)
+				return
+			}
+
+			// Queue behind notifications of other processes. Until the lock
+			// is held nothing needs restoring, so Ctrl+C simply exits.
+			unlock, err := effect.Lock(effect.DefaultLockPath())
+			if err != nil {
+				fmt.Printf("Failed to queue notification: %v\n", err)
+				return
+			}
+			defer unlock()
+
+			ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+			defer stop()
+
+			err = effect.Play(ctx, freshClient(), ToLights(lightConfigs), effect.Options{
+				Effect:     e,
+				Count:      notifyCount,
+				Period:     period,
+				Brightness: notifyBrightness,
+			})
+			if err != nil {
+				fmt.Println(err)
+			}
+		},
+	}
+)
+
+func init() {
+	addSelectorFlags(notifyCmd, &notifySelector)
+	notifyCmd.Flags().StringVarP(&notifyEffect, "effect", "e", string(effect.Blink), "Effect to play: blink, pulse or breathe")
+	notifyCmd.Flags().IntVarP(&notifyCount, "count", "c", 3, "Number of times to play the effect")
+	notifyCmd.Flags().DurationVar(&notifyPeriod, "period", 0, "Duration of one cycle of the effect (default depends on the effect)")
+	notifyCmd.Flags().IntVarP(&notifyBrightness, "brightness", "b", 100, "Peak brightness percentage (0-100)")
+
+	rootCmd.AddCommand(notifyCmd)
+}
@@ -0,0 +1,63 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/eckertalex/keylightctl/internal/obs"
+	"github.com/eckertalex/keylightctl/internal/scene"
+	"github.com/spf13/cobra"
+)
+
+var (
+	obsURL      string
+	obsPassword string
+	obsCmd      = &cobra.Command{
+		Use:   "obs",
+		Short: "Apply scenes when OBS Studio starts streaming, recording or switches scenes",
+		Args:  cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			config := obsConfig
+			if cmd.Flags().Changed("url") {
+				config.URL = obsURL
+			}
+			switch {
+			case cmd.Flags().Changed("password"):
+				config.Password = obsPassword
+			case os.Getenv("KEYLIGHTCTL_OBS_PASSWORD") != "":
+				config.Password = os.Getenv("KEYLIGHTCTL_OBS_PASSWORD")
+			}
+
+			if len(config.Triggers) == 0 {
+				fmt.Println("No OBS triggers configured")
+				return
+			}
+
+			client := lightClient()
+			runner := obs.NewRunner(config, func(ctx context.Context, t obs.Trigger) error {
+				targets, err := scene.Resolve(*scene.Find(scenesConfig, t.Scene), lightsConfig, groupsConfig)
+				if err != nil {
+					return err
+				}
+				return applyTargets(ctx, client, targets, t.Fade)
+			})
+
+			ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+			defer stop()
+
+			if err := runner.Run(ctx); err != nil {
+				fmt.Printf("OBS integration failed: %v\n", err)
+			}
+		},
+	}
+)
+
+func init() {
+	obsCmd.Flags().StringVar(&obsURL, "url", obs.DefaultURL, "URL of obs-websocket")
+	obsCmd.Flags().StringVar(&obsPassword, "password", "", "obs-websocket password (default $KEYLIGHTCTL_OBS_PASSWORD)")
+
+	rootCmd.AddCommand(obsCmd)
+}
@@ -8,33 +8,25 @@ import (
 )
 
 var (
-	offLightName string
-	offCmd       = &cobra.Command{
+	offSelector lightSelector
+	offCmd      = &cobra.Command{
 		Use:   "off",
 		Short: "Turn off the lights",
 		Run: func(cmd *cobra.Command, args []string) {
-			if offLightName != "" {
-				lightConfig := FindLightByName(lightsConfig, offLightName)
-
-				if lightConfig == nil {
-					availableLights := GetAvailableLightNames(lightsConfig)
-					fmt.Printf("Light '%s' not found. Available lights: %s\n", offLightName, availableLights)
-					return
-				}
-
-				lights := ToLights([]keylight.LightConfig{*lightConfig})
-				UpdateLightsSettings(lights, keylight.LightDetail{On: 0})
+			lightConfigs, err := offSelector.resolve(lightsConfig, groupsConfig)
+			if err != nil {
+				fmt.Println(err)
 				return
 			}
 
-			lights := ToLights(lightsConfig)
+			lights := ToLights(lightConfigs)
 			UpdateLightsSettings(lights, keylight.LightDetail{On: 0})
 		},
 	}
 )
 
 func init() {
-	offCmd.Flags().StringVarP(&offLightName, "light", "l", "", "Specify the light name to turn off")
+	addSelectorFlags(offCmd, &offSelector)
 
 	rootCmd.AddCommand(offCmd)
 }
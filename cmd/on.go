@@ -10,7 +10,7 @@ import (
 var (
 	onBrightness  *int
 	onTemperature *int
-	onLightName   string
+	onSelector    lightSelector
 	onCmd         = &cobra.Command{
 		Use:   "on",
 		Short: "Turn on the lights",
@@ -20,7 +20,7 @@ var (
 			}
 
 			if cmd.Flags().Changed("brightness") {
-				if err := ValidateBrightness(*onBrightness); err != nil {
+				if err := keylight.ValidateBrightness(*onBrightness); err != nil {
 					fmt.Printf("Invalid brightness: %v\n", err)
 					return
 				}
@@ -28,28 +28,20 @@ var (
 			}
 
 			if cmd.Flags().Changed("temperature") {
-				if err := ValidateTemperature(*onTemperature); err != nil {
+				if err := keylight.ValidateTemperature(*onTemperature); err != nil {
 					fmt.Printf("Invalid temperature: %v\n", err)
 					return
 				}
 				settings.Temperature = keylight.KelvinToMired(*onTemperature)
 			}
 
-			if onLightName != "" {
-				lightConfig := FindLightByName(lightsConfig, onLightName)
-
-				if lightConfig == nil {
-					availableLights := GetAvailableLightNames(lightsConfig)
-					fmt.Printf("Light '%s' not found. Available lights: %s\n", onLightName, availableLights)
-					return
-				}
-
-				lights := ToLights([]keylight.LightConfig{*lightConfig})
-				UpdateLightsSettings(lights, settings)
+			lightConfigs, err := onSelector.resolve(lightsConfig, groupsConfig)
+			if err != nil {
+				fmt.Println(err)
 				return
 			}
 
-			lights := ToLights(lightsConfig)
+			lights := ToLights(lightConfigs)
 			UpdateLightsSettings(lights, settings)
 		},
 	}
@@ -61,7 +53,7 @@ func init() {
 
 	onCmd.Flags().IntVarP(onBrightness, "brightness", "b", 0, "Brightness percentage (0-100)")
 	onCmd.Flags().IntVarP(onTemperature, "temperature", "t", 0, "Color temperature in Kelvin (2900-7000)")
-	onCmd.Flags().StringVarP(&onLightName, "light", "l", "", "Specify the light name")
+	addSelectorFlags(onCmd, &onSelector)
 
 	rootCmd.AddCommand(onCmd)
 }
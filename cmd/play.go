@@ -0,0 +1,86 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/eckertalex/keylightctl/internal/animation"
+	"github.com/spf13/cobra"
+)
+
+var (
+	playDryRun bool
+	playRepeat int
+	playLoop   bool
+	playStep   time.Duration
+	playCmd    = &cobra.Command{
+		Use:   "play <file>",
+		Short: "Play a timeline of keyframes from a JSON or TOML file",
+		Args:  cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			timeline, err := animation.Load(args[0], lightsConfig, groupsConfig)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Failed to load timeline: %v\n", err)
+				os.Exit(1)
+			}
+			if cmd.Flags().Changed("repeat") {
+				timeline.Repeat = playRepeat
+			}
+			if cmd.Flags().Changed("loop") {
+				timeline.Loop = playLoop
+			}
+			if timeline.Repeat <= 0 {
+				fmt.Fprintln(os.Stderr, "Invalid repeat: must be positive")
+				os.Exit(1)
+			}
+			if timeline.Loop && timeline.Duration() == 0 {
+				fmt.Fprintln(os.Stderr, "Cannot loop a timeline without duration")
+				os.Exit(1)
+			}
+			if playStep <= 0 {
+				fmt.Fprintln(os.Stderr, "Invalid step: must be positive")
+				os.Exit(1)
+			}
+
+			requests, err := animation.Compile(*timeline, lightsConfig, groupsConfig, playStep)
+			if err != nil {
+				fmt.Fprintln(os.Stderr, err)
+				os.Exit(1)
+			}
+
+			if playDryRun {
+				for _, r := range requests {
+					fmt.Printf("%10s  %-16s %s\n", r.At, r.Light.Name, r.State)
+				}
+				switch {
+				case timeline.Loop:
+					fmt.Printf("Looping every %s until interrupted\n", timeline.Duration())
+				case timeline.Repeat > 1:
+					fmt.Printf("Repeating %d times, every %s\n", timeline.Repeat, timeline.Duration())
+				}
+				return
+			}
+
+			ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+			err = animation.Play(ctx, lightClient(), requests, timeline.Duration(), timeline.Repeat, timeline.Loop)
+			stop()
+			if err != nil {
+				fmt.Fprintln(os.Stderr, err)
+				os.Exit(1)
+			}
+		},
+	}
+)
+
+func init() {
+	playCmd.Flags().BoolVar(&playDryRun, "dry-run", false, "Print the computed requests per light instead of playing them")
+	playCmd.Flags().IntVar(&playRepeat, "repeat", 1, "Number of times to play the timeline (overrides the file)")
+	playCmd.Flags().BoolVar(&playLoop, "loop", false, "Play the timeline until interrupted (overrides the file)")
+	playCmd.Flags().DurationVar(&playStep, "step", 100*time.Millisecond, "Interval between interpolated requests")
+
+	rootCmd.AddCommand(playCmd)
+}
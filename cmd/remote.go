@@ -0,0 +1,97 @@
+package cmd
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net/http"
+	"os"
+
+	"github.com/eckertalex/keylightctl/api"
+	"github.com/eckertalex/keylightctl/internal/keylight"
+	"github.com/eckertalex/keylightctl/internal/scene"
+	"github.com/spf13/viper"
+)
+
+// remoteURL returns the URL of the remote daemon, or "" when not in remote
+// mode. --direct overrides a remote set in the config file.
+func remoteURL() string {
+	if forceDirect {
+		return ""
+	}
+	return viper.GetString("remote.url")
+}
+
+// newRemoteClient returns a client for the daemon configured with --remote
+// or the [remote] config table.
+func newRemoteClient() (*api.Client, error) {
+	client := api.NewClient(remoteURL())
+	client.Token = viper.GetString("remote.token")
+
+	if caFile := viper.GetString("remote.ca_file"); caFile != "" {
+		pem, err := os.ReadFile(caFile)
+		if err != nil {
+			return nil, fmt.Errorf("reading CA file: %w", err)
+		}
+
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no certificates found in CA file %s", caFile)
+		}
+		client.HTTPClient.Transport = &http.Transport{
+			TLSClientConfig: &tls.Config{RootCAs: pool},
+		}
+	}
+	return client, nil
+}
+
+// loadRemoteConfig replaces the local lights, groups and scenes with those of
+// the remote daemon.
+func loadRemoteConfig(client *api.Client) error {
+	ctx, cancel := context.WithTimeout(context.Background(), daemonTimeout)
+	defer cancel()
+
+	lights, err := client.Lights(ctx)
+	if err != nil {
+		return fmt.Errorf("fetching lights: %w", err)
+	}
+	groups, err := client.Groups(ctx)
+	if err != nil {
+		return fmt.Errorf("fetching groups: %w", err)
+	}
+	scenes, err := client.Scenes(ctx)
+	if err != nil {
+		return fmt.Errorf("fetching scenes: %w", err)
+	}
+
+	lightsConfig = make([]keylight.LightConfig, len(lights))
+	for i, light := range lights {
+		lightsConfig[i] = keylight.LightConfig{
+			Light: keylight.Light{Name: light.Name, IP: light.IP},
+			Tags:  light.Tags,
+		}
+	}
+
+	groupsConfig = make([]keylight.GroupConfig, len(groups))
+	for i, group := range groups {
+		groupsConfig[i] = keylight.GroupConfig{Name: group.Name, Lights: group.Lights, Groups: group.Groups}
+	}
+
+	scenesConfig = make([]scene.Scene, len(scenes))
+	for i, s := range scenes {
+		scenesConfig[i] = scene.Scene{
+			Name:          s.Name,
+			Description:   s.Description,
+			TurnOffOthers: s.TurnOffOthers,
+		}
+		for _, entry := range s.Lights {
+			scenesConfig[i].Lights = append(scenesConfig[i].Lights, scene.LightState{
+				Light: entry.Light,
+				Group: entry.Group,
+				State: scene.State{On: entry.On, Brightness: entry.Brightness, Temperature: entry.Temperature},
+			})
+		}
+	}
+	return nil
+}
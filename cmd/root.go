@@ -1,23 +1,41 @@
 package cmd
 
 import (
+	"errors"
 	"fmt"
+	"io/fs"
 	"os"
 
+	"github.com/eckertalex/keylightctl/internal/calendar"
+	"github.com/eckertalex/keylightctl/internal/camera"
+	"github.com/eckertalex/keylightctl/internal/circadian"
+	"github.com/eckertalex/keylightctl/internal/follow"
 	"github.com/eckertalex/keylightctl/internal/keylight"
+	"github.com/eckertalex/keylightctl/internal/obs"
+	"github.com/eckertalex/keylightctl/internal/scene"
+	"github.com/eckertalex/keylightctl/internal/schedule"
+	"github.com/eckertalex/keylightctl/internal/server"
 	"github.com/eckertalex/keylightctl/tui"
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
 )
 
 var (
-	lightsConfig []keylight.LightConfig
-	cfgFile      string
-	rootCmd      = &cobra.Command{
+	lightsConfig    []keylight.LightConfig
+	groupsConfig    []keylight.GroupConfig
+	scenesConfig    []scene.Scene
+	tokensConfig    []server.TokenConfig
+	schedulesConfig []schedule.Schedule
+	calendarsConfig []calendar.Calendar
+	circadianConfig circadian.Config
+	autoConfig      camera.Config
+	obsConfig       obs.Config
+	cfgFile         string
+	rootCmd         = &cobra.Command{
 		Use:   "keylightctl",
 		Short: "A CLI to manage your Elgato Key Light Air",
 		Run: func(cmd *cobra.Command, args []string) {
-			if err := tui.Run(lightsConfig); err != nil {
+			if err := tui.Run(lightsConfig, groupsConfig, lightClient()); err != nil {
 				fmt.Println("Error running TUI:", err)
 			}
 		},
@@ -33,6 +51,14 @@ func init() {
 	cobra.OnInitialize(initConfig)
 
 	rootCmd.PersistentFlags().StringVar(&cfgFile, "config", "", "config file (default is $HOME/.keylightctl.toml)")
+	rootCmd.PersistentFlags().BoolVar(&forceDirect, "direct", false, "talk to the lights directly, even if a daemon is running")
+	rootCmd.PersistentFlags().String("remote", "", "URL of a remote keylightctl daemon to control the lights through")
+	rootCmd.PersistentFlags().String("remote-ca", "", "CA certificate file used to verify the remote daemon")
+	rootCmd.MarkFlagsMutuallyExclusive("direct", "remote")
+
+	viper.BindPFlag("remote.url", rootCmd.PersistentFlags().Lookup("remote"))
+	viper.BindPFlag("remote.ca_file", rootCmd.PersistentFlags().Lookup("remote-ca"))
+	viper.BindEnv("remote.token", "KEYLIGHTCTL_REMOTE_TOKEN")
 }
 
 func initConfig() {
@@ -49,12 +75,133 @@ func initConfig() {
 	viper.AutomaticEnv()
 
 	if err := viper.ReadInConfig(); err != nil {
-		fmt.Fprintf(os.Stderr, "Failed to read config file: %v\n", err)
-		os.Exit(1)
+		// In remote mode the lights are configured on the remote host, so a
+		// local config file is optional.
+		var notFound viper.ConfigFileNotFoundError
+		if remoteURL() == "" || !(errors.As(err, &notFound) || errors.Is(err, fs.ErrNotExist)) {
+			fmt.Fprintf(os.Stderr, "Failed to read config file: %v\n", err)
+			os.Exit(1)
+		}
+	}
+
+	if remoteURL() != "" {
+		client, err := newRemoteClient()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to configure remote: %v\n", err)
+			os.Exit(1)
+		}
+		if err := loadRemoteConfig(client); err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to load config from remote: %v\n", err)
+			os.Exit(1)
+		}
+		remoteClient = client
+
+		// The automations run locally against the remote lights, so their
+		// config is still read from the local file.
+		loadAutomationsConfig()
+		return
 	}
 
 	if err := viper.UnmarshalKey("lights", &lightsConfig); err != nil {
 		fmt.Fprintf(os.Stderr, "Failed to unmarshal lights: %v\n", err)
 		os.Exit(1)
 	}
+
+	if err := viper.UnmarshalKey("groups", &groupsConfig); err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to unmarshal groups: %v\n", err)
+		os.Exit(1)
+	}
+
+	if err := follow.Validate(lightsConfig); err != nil {
+		fmt.Fprintf(os.Stderr, "Invalid lights: %v\n", err)
+		os.Exit(1)
+	}
+
+	if err := keylight.ValidateGroups(groupsConfig, lightsConfig); err != nil {
+		fmt.Fprintf(os.Stderr, "Invalid groups: %v\n", err)
+		os.Exit(1)
+	}
+
+	if err := viper.UnmarshalKey("scenes", &scenesConfig); err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to unmarshal scenes: %v\n", err)
+		os.Exit(1)
+	}
+
+	if err := scene.Validate(scenesConfig, lightsConfig, groupsConfig); err != nil {
+		fmt.Fprintf(os.Stderr, "Invalid scenes: %v\n", err)
+		os.Exit(1)
+	}
+
+	loadAutomationsConfig()
+
+	if err := viper.UnmarshalKey("tokens", &tokensConfig); err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to unmarshal tokens: %v\n", err)
+		os.Exit(1)
+	}
+
+	if err := server.ValidateTokens(tokensConfig, lightsConfig, groupsConfig); err != nil {
+		fmt.Fprintf(os.Stderr, "Invalid tokens: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+// loadAutomationsConfig reads and validates the schedules, calendars,
+// circadian, auto and obs blocks against the lights, groups and scenes
+// already loaded.
+func loadAutomationsConfig() {
+	if err := viper.UnmarshalKey("schedules", &schedulesConfig); err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to unmarshal schedules: %v\n", err)
+		os.Exit(1)
+	}
+
+	if err := schedule.Validate(schedulesConfig, scenesConfig, lightsConfig, groupsConfig); err != nil {
+		fmt.Fprintf(os.Stderr, "Invalid schedules: %v\n", err)
+		os.Exit(1)
+	}
+
+	if err := viper.UnmarshalKey("calendars", &calendarsConfig); err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to unmarshal calendars: %v\n", err)
+		os.Exit(1)
+	}
+
+	if err := calendar.Validate(calendarsConfig, scenesConfig); err != nil {
+		fmt.Fprintf(os.Stderr, "Invalid calendars: %v\n", err)
+		os.Exit(1)
+	}
+
+	if viper.IsSet("circadian") {
+		if err := viper.UnmarshalKey("circadian", &circadianConfig); err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to unmarshal circadian: %v\n", err)
+			os.Exit(1)
+		}
+
+		if err := circadian.Validate(&circadianConfig); err != nil {
+			fmt.Fprintf(os.Stderr, "Invalid circadian: %v\n", err)
+			os.Exit(1)
+		}
+	}
+
+	if err := viper.UnmarshalKey("auto", &autoConfig); err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to unmarshal auto: %v\n", err)
+		os.Exit(1)
+	}
+	// An explicit grace of zero restores the lights right away.
+	if !viper.IsSet("auto.grace") {
+		autoConfig.Grace = camera.DefaultGrace
+	}
+
+	if err := camera.Validate(&autoConfig); err != nil {
+		fmt.Fprintf(os.Stderr, "Invalid auto: %v\n", err)
+		os.Exit(1)
+	}
+
+	if err := viper.UnmarshalKey("obs", &obsConfig); err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to unmarshal obs: %v\n", err)
+		os.Exit(1)
+	}
+
+	if err := obs.Validate(&obsConfig, scenesConfig); err != nil {
+		fmt.Fprintf(os.Stderr, "Invalid obs: %v\n", err)
+		os.Exit(1)
+	}
 }
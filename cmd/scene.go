@@ -0,0 +1,210 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"time"
+
+	"github.com/eckertalex/keylightctl/internal/keylight"
+	"github.com/eckertalex/keylightctl/internal/scene"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+var (
+	sceneFade            time.Duration
+	sceneSaveSelector    lightSelector
+	sceneSaveForce       bool
+	sceneSaveDiff        bool
+	sceneSaveDescription string
+	sceneCmd             = &cobra.Command{
+		Use:   "scene",
+		Short: "Manage and apply scenes",
+	}
+	sceneApplyCmd = &cobra.Command{
+		Use:   "apply <name>",
+		Short: "Apply a scene",
+		Args:  cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			s, err := findScene(args[0])
+			if err != nil {
+				fmt.Println(err)
+				return
+			}
+
+			targets, err := scene.Resolve(*s, lightsConfig, groupsConfig)
+			if err != nil {
+				fmt.Println(err)
+				return
+			}
+
+			ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+			defer stop()
+
+			ApplySceneTargets(ctx, targets, sceneFade)
+		},
+	}
+	sceneListCmd = &cobra.Command{
+		Use:   "list",
+		Short: "List the configured scenes",
+		Args:  cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			for _, s := range scenesConfig {
+				if s.Description != "" {
+					fmt.Printf("%s - %s\n", s.Name, s.Description)
+					continue
+				}
+				fmt.Println(s.Name)
+			}
+		},
+	}
+	sceneSaveCmd = &cobra.Command{
+		Use:   "save <name>",
+		Short: "Save the current state of the lights as a scene",
+		Args:  cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			if remoteURL() != "" {
+				fmt.Println("Scenes can only be saved on the host running the daemon")
+				return
+			}
+
+			name := args[0]
+
+			lightConfigs, err := sceneSaveSelector.resolve(lightsConfig, groupsConfig)
+			if err != nil {
+				fmt.Println(err)
+				return
+			}
+
+			lights := ToLights(lightConfigs)
+			controller := lightClient()
+			details := make([]keylight.LightDetail, len(lights))
+			for i, result := range collectLightResults(lights, controller.GetLight) {
+				if result.err != nil {
+					fmt.Printf("Status of light \"%s\": Error: %s\n", result.name, describeError(result.err))
+					return
+				}
+				if len(result.status.Lights) == 0 {
+					fmt.Printf("Status of light \"%s\": Error: empty status\n", result.name)
+					return
+				}
+				details[i] = result.status.Lights[0]
+			}
+
+			captured := scene.Capture(name, lights, details)
+			captured.Description = sceneSaveDescription
+
+			existing := scene.Find(scenesConfig, name)
+			if existing != nil {
+				// Keep the scene-level settings, only the lights are
+				// captured.
+				if captured.Description == "" {
+					captured.Description = existing.Description
+				}
+				captured.TurnOffOthers = existing.TurnOffOthers
+
+				if sceneSaveDiff {
+					changes, err := scene.Diff(*existing, captured, lightsConfig, groupsConfig)
+					if err != nil {
+						fmt.Println(err)
+						return
+					}
+					printSceneDiff(name, changes)
+				}
+
+				if !sceneSaveForce {
+					fmt.Printf("Scene '%s' already exists. Use --force to overwrite it.\n", name)
+					return
+				}
+			}
+
+			path := viper.ConfigFileUsed()
+			if err := scene.Save(path, captured); err != nil {
+				fmt.Printf("Failed to save scene: %v\n", err)
+				return
+			}
+			fmt.Printf("Saved scene \"%s\" with %d lights to %s\n", name, len(lights), path)
+		},
+	}
+	sceneShowCmd = &cobra.Command{
+		Use:   "show <name>",
+		Short: "Show the lights and states of a scene",
+		Args:  cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			s, err := findScene(args[0])
+			if err != nil {
+				fmt.Println(err)
+				return
+			}
+
+			fmt.Printf("Scene \"%s\":\n", s.Name)
+			if s.Description != "" {
+				fmt.Printf("  %s\n", s.Description)
+			}
+			for _, entry := range s.Lights {
+				if entry.Group != "" {
+					fmt.Printf("  Group \"%s\": %s\n", entry.Group, entry.State)
+					continue
+				}
+				fmt.Printf("  Light \"%s\": %s\n", entry.Light, entry.State)
+			}
+			if s.TurnOffOthers {
+				fmt.Println("  Other lights: OFF")
+			}
+		},
+	}
+)
+
+func findScene(name string) (*scene.Scene, error) {
+	s := scene.Find(scenesConfig, name)
+	if s == nil {
+		names := make([]string, 0, len(scenesConfig))
+		for _, s := range scenesConfig {
+			names = append(names, s.Name)
+		}
+		return nil, &selectorError{problems: []string{describeUnmatched("Scene", name, names)}}
+	}
+	return s, nil
+}
+
+func printSceneDiff(name string, changes []string) {
+	if len(changes) == 0 {
+		fmt.Printf("Scene \"%s\" is unchanged\n", name)
+		return
+	}
+
+	fmt.Printf("Changes to scene \"%s\":\n", name)
+	for _, change := range changes {
+		fmt.Printf("  %s\n", change)
+	}
+}
+
+func ApplySceneTargets(ctx context.Context, targets []scene.Target, fade time.Duration) {
+	controller := lightClient()
+
+	states := make(map[string]scene.State, len(targets))
+	lights := make([]keylight.Light, 0, len(targets))
+	for _, target := range targets {
+		states[target.Light.IP] = target.State
+		lights = append(lights, target.Light)
+	}
+
+	applyOperation := func(ip string) (*keylight.LightStatus, error) {
+		return scene.ApplyState(ctx, controller, ip, states[ip], fade)
+	}
+	processLightOperation(lights, applyOperation, "Update")
+}
+
+func init() {
+	sceneApplyCmd.Flags().DurationVar(&sceneFade, "fade", 0, "Fade to the scene over this duration (e.g. 2s)")
+
+	addSelectorFlags(sceneSaveCmd, &sceneSaveSelector)
+	sceneSaveCmd.Flags().BoolVar(&sceneSaveForce, "force", false, "Overwrite an existing scene")
+	sceneSaveCmd.Flags().BoolVar(&sceneSaveDiff, "diff", false, "Show the changes against an existing scene")
+	sceneSaveCmd.Flags().StringVar(&sceneSaveDescription, "description", "", "Description of the scene")
+
+	sceneCmd.AddCommand(sceneApplyCmd, sceneListCmd, sceneSaveCmd, sceneShowCmd)
+	rootCmd.AddCommand(sceneCmd)
+}
@@ -0,0 +1,183 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/eckertalex/keylightctl/internal/alarm"
+	"github.com/eckertalex/keylightctl/internal/calendar"
+	"github.com/eckertalex/keylightctl/internal/keylight"
+	"github.com/eckertalex/keylightctl/internal/scene"
+	"github.com/eckertalex/keylightctl/internal/schedule"
+	"github.com/spf13/cobra"
+)
+
+var (
+	scheduleNext int
+	scheduleCmd  = &cobra.Command{
+		Use:   "schedule",
+		Short: "List and run the configured schedules",
+	}
+	scheduleListCmd = &cobra.Command{
+		Use:   "list",
+		Short: "List the configured schedules",
+		Args:  cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			if len(schedulesConfig) == 0 && len(calendarsConfig) == 0 {
+				fmt.Println("No schedules configured")
+				return
+			}
+
+			if scheduleNext > 0 {
+				runs, err := schedule.Upcoming(schedulesConfig, time.Now(), scheduleNext)
+				if err != nil {
+					fmt.Println(err)
+					return
+				}
+				for _, run := range runs {
+					fmt.Printf("%s  %s: %s\n", run.At.Format("Mon 2006-01-02 15:04 MST"), run.Schedule.Name, run.Schedule.Action())
+				}
+				return
+			}
+
+			for _, s := range schedulesConfig {
+				fmt.Printf("%s - %s: %s\n", s.Name, s.When(), s.Action())
+			}
+			for _, c := range calendarsConfig {
+				fmt.Printf("%s - %s before %s in %s: scene %s\n", c.Name, c.Before, c.Filter(), c.Path, c.Scene)
+			}
+		},
+	}
+	scheduleRunCmd = &cobra.Command{
+		Use:   "run",
+		Short: "Run the configured schedules and calendars in the foreground",
+		Args:  cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			if len(schedulesConfig) == 0 && len(calendarsConfig) == 0 {
+				fmt.Println("No schedules configured")
+				return
+			}
+
+			// A local daemon runs the same schedules already. A remote one
+			// runs its own, so the local ones are run against it.
+			client := lightClient()
+			if _, ok := client.(*daemonClient); ok && remoteClient == nil {
+				fmt.Println("A daemon is running and already runs the schedules")
+				return
+			}
+
+			ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+			defer stop()
+
+			// Wait for the calendars to revert the lights of running events.
+			calendarsDone := make(chan struct{})
+			go func() {
+				defer close(calendarsDone)
+				calendar.NewRunner(calendarsConfig, func(ctx context.Context, c calendar.Calendar) (func(context.Context) error, error) {
+					return startCalendar(ctx, freshClient(), c)
+				}).Run(ctx)
+			}()
+
+			schedule.NewRunner(schedulesConfig, func(ctx context.Context, s schedule.Schedule, now time.Time) error {
+				return runSchedule(ctx, client, s, now)
+			}).Run(ctx)
+			<-calendarsDone
+		},
+	}
+)
+
+func runSchedule(ctx context.Context, client keylight.Client, s schedule.Schedule, now time.Time) error {
+	targets, err := s.Targets(scenesConfig, lightsConfig, groupsConfig)
+	if err != nil {
+		return err
+	}
+	if s.Sunrise > 0 {
+		return startSunrise(ctx, client, s, targets, now)
+	}
+	return applyTargets(ctx, client, targets, s.Fade)
+}
+
+// startSunrise ramps up the lights of a sunrise schedule in the background,
+// so that other schedules run meanwhile.
+func startSunrise(ctx context.Context, client keylight.Client, s schedule.Schedule, targets []scene.Target, now time.Time) error {
+	end, err := s.SunriseEnd(now)
+	if err != nil {
+		return err
+	}
+	go alarm.NewSunrise(client, targets, end, s.Sunrise).Run(ctx)
+	return nil
+}
+
+// startCalendar applies the scene of a calendar and returns a function that
+// restores the exact previous state of its lights.
+func startCalendar(ctx context.Context, client keylight.Client, c calendar.Calendar) (func(context.Context) error, error) {
+	targets, err := scene.Resolve(*scene.Find(scenesConfig, c.Scene), lightsConfig, groupsConfig)
+	if err != nil {
+		return nil, err
+	}
+	return calendar.Start(ctx, calendarLights{client}, targets, c.Fade)
+}
+
+// calendarLights reads and changes the lights of calendar events through a
+// client.
+type calendarLights struct {
+	client keylight.Client
+}
+
+func (l calendarLights) Capture(light keylight.Light) (keylight.LightDetail, error) {
+	status, err := l.client.GetLight(light.IP)
+	if err != nil {
+		return keylight.LightDetail{}, errors.New(describeError(err))
+	}
+	if len(status.Lights) == 0 {
+		return keylight.LightDetail{}, errors.New("empty status")
+	}
+	return status.Lights[0], nil
+}
+
+func (l calendarLights) Apply(ctx context.Context, target scene.Target, fade time.Duration) error {
+	if _, err := scene.ApplyState(ctx, l.client, target.Light.IP, target.State, fade); err != nil {
+		return errors.New(describeError(err))
+	}
+	return nil
+}
+
+func (l calendarLights) Restore(light keylight.Light, detail keylight.LightDetail) error {
+	if _, err := l.client.UpdateLight(light.IP, detail); err != nil {
+		return errors.New(describeError(err))
+	}
+	return nil
+}
+
+// applyTargets moves every target light to its state and returns the
+// failures.
+func applyTargets(ctx context.Context, client keylight.Client, targets []scene.Target, fade time.Duration) error {
+	states := make(map[string]scene.State, len(targets))
+	lights := make([]keylight.Light, 0, len(targets))
+	for _, target := range targets {
+		states[target.Light.IP] = target.State
+		lights = append(lights, target.Light)
+	}
+
+	var errs []error
+	for _, result := range collectLightResults(lights, func(ip string) (*keylight.LightStatus, error) {
+		return scene.ApplyState(ctx, client, ip, states[ip], fade)
+	}) {
+		if result.err != nil {
+			errs = append(errs, fmt.Errorf("%s: %s", result.name, describeError(result.err)))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func init() {
+	scheduleListCmd.Flags().IntVar(&scheduleNext, "next", 0, "Show the next N runs of all schedules instead")
+
+	scheduleCmd.AddCommand(scheduleListCmd, scheduleRunCmd)
+	rootCmd.AddCommand(scheduleCmd)
+}
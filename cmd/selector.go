@@ -0,0 +1,189 @@
+package cmd
+
+import (
+	"fmt"
+	"path"
+	"slices"
+	"strings"
+
+	"github.com/eckertalex/keylightctl/internal/keylight"
+	"github.com/spf13/cobra"
+)
+
+// lightSelector holds the --light, --tag and --group flags shared by all
+// commands that act on a subset of the configured lights.
+type lightSelector struct {
+	names  []string
+	tags   []string
+	groups []string
+}
+
+func addSelectorFlags(cmd *cobra.Command, sel *lightSelector) {
+	cmd.Flags().StringSliceVarP(&sel.names, "light", "l", nil, "Light name or glob pattern (repeatable)")
+	cmd.Flags().StringSliceVar(&sel.tags, "tag", nil, "Select lights with this tag (repeatable)")
+	cmd.Flags().StringSliceVarP(&sel.groups, "group", "g", nil, "Select the lights of this group (repeatable)")
+}
+
+func (s lightSelector) empty() bool {
+	return len(s.names) == 0 && len(s.tags) == 0 && len(s.groups) == 0
+}
+
+// resolve returns the configured lights matched by any of the selectors, in
+// configuration order. Without selectors every light is returned. Every
+// selector that matches nothing is reported in the returned error.
+func (s lightSelector) resolve(configs []keylight.LightConfig, groups []keylight.GroupConfig) ([]keylight.LightConfig, error) {
+	if s.empty() {
+		return configs, nil
+	}
+
+	selected := make([]bool, len(configs))
+	var unmatched []string
+
+	for _, name := range s.names {
+		matched := false
+		for i, cfg := range configs {
+			ok, err := matchName(name, cfg.Name)
+			if err != nil {
+				return nil, fmt.Errorf("invalid light pattern '%s': %w", name, err)
+			}
+			if ok {
+				selected[i] = true
+				matched = true
+			}
+		}
+		if !matched {
+			unmatched = append(unmatched, describeUnmatched("Light", name, lightNames(configs)))
+		}
+	}
+
+	for _, tag := range s.tags {
+		matched := false
+		for i, cfg := range configs {
+			if slices.Contains(cfg.Tags, tag) {
+				selected[i] = true
+				matched = true
+			}
+		}
+		if !matched {
+			unmatched = append(unmatched, describeUnmatched("Tag", tag, lightTags(configs)))
+		}
+	}
+
+	for _, group := range s.groups {
+		if keylight.FindGroup(groups, group) == nil {
+			unmatched = append(unmatched, describeUnmatched("Group", group, groupNames(groups)))
+			continue
+		}
+
+		members, err := keylight.ExpandGroup(groups, group)
+		if err != nil {
+			return nil, err
+		}
+		for i, cfg := range configs {
+			if slices.Contains(members, cfg.Name) {
+				selected[i] = true
+			}
+		}
+	}
+
+	if len(unmatched) > 0 {
+		return nil, &selectorError{problems: unmatched}
+	}
+
+	var lights []keylight.LightConfig
+	for i, cfg := range configs {
+		if selected[i] {
+			lights = append(lights, cfg)
+		}
+	}
+	return lights, nil
+}
+
+type selectorError struct {
+	problems []string
+}
+
+func (e *selectorError) Error() string {
+	return strings.Join(e.problems, "\n")
+}
+
+func matchName(pattern, name string) (bool, error) {
+	if !strings.ContainsAny(pattern, "*?[") {
+		return pattern == name, nil
+	}
+	return path.Match(pattern, name)
+}
+
+func describeUnmatched(kind, value string, available []string) string {
+	if suggestions := suggest(value, available); len(suggestions) > 0 {
+		return fmt.Sprintf("%s '%s' not found. Did you mean: %s?", kind, value, strings.Join(suggestions, ", "))
+	}
+	return fmt.Sprintf("%s '%s' not found. Available: %s", kind, value, strings.Join(available, ", "))
+}
+
+// suggest returns the candidates that are close to value, either by a small
+// edit distance or by a case-insensitive substring match.
+func suggest(value string, candidates []string) []string {
+	lower := strings.ToLower(value)
+	needle := strings.Trim(lower, "*?[]")
+	maxDistance := max(2, len(value)/3)
+
+	var suggestions []string
+	for _, candidate := range candidates {
+		c := strings.ToLower(candidate)
+		if levenshtein(lower, c) <= maxDistance || (needle != "" && strings.Contains(c, needle)) {
+			suggestions = append(suggestions, candidate)
+		}
+	}
+	return suggestions
+}
+
+func levenshtein(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	prev := make([]int, len(rb)+1)
+	curr := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+
+	for i := 1; i <= len(ra); i++ {
+		curr[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			curr[j] = min(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+		}
+		prev, curr = curr, prev
+	}
+	return prev[len(rb)]
+}
+
+func lightNames(configs []keylight.LightConfig) []string {
+	names := make([]string, 0, len(configs))
+	for _, cfg := range configs {
+		names = append(names, cfg.Name)
+	}
+	return names
+}
+
+func lightTags(configs []keylight.LightConfig) []string {
+	var tags []string
+	for _, cfg := range configs {
+		for _, tag := range cfg.Tags {
+			if !slices.Contains(tags, tag) {
+				tags = append(tags, tag)
+			}
+		}
+	}
+	return tags
+}
+
+func groupNames(groups []keylight.GroupConfig) []string {
+	names := make([]string, 0, len(groups))
+	for _, group := range groups {
+		names = append(names, group.Name)
+	}
+	return names
+}
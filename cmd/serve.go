@@ -0,0 +1,255 @@
+package cmd
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/eckertalex/keylightctl/api"
+	"github.com/eckertalex/keylightctl/internal/calendar"
+	"github.com/eckertalex/keylightctl/internal/keylight"
+	"github.com/eckertalex/keylightctl/internal/metrics"
+	"github.com/eckertalex/keylightctl/internal/schedule"
+	"github.com/eckertalex/keylightctl/internal/server"
+	"github.com/spf13/cobra"
+)
+
+var (
+	serveListen         string
+	serveSocket         string
+	serveRefresh        time.Duration
+	serveEventBuffer    int
+	serveSlowSubscriber string
+	serveToken          string
+	serveInsecure       bool
+	serveTLSCert        string
+	serveTLSKey         string
+	serveAuditLog       string
+	serveMetrics        bool
+	serveSchedules      bool
+	serveCmd            = &cobra.Command{
+		Use:   "serve",
+		Short: "Run a daemon exposing the lights over a REST API",
+		Run: func(cmd *cobra.Command, args []string) {
+			if remoteURL() != "" {
+				fmt.Println("serve cannot run in remote mode")
+				return
+			}
+
+			if serveRefresh <= 0 {
+				fmt.Println("Invalid refresh interval: must be positive")
+				return
+			}
+
+			if serveEventBuffer <= 0 {
+				fmt.Println("Invalid event buffer: must be positive")
+				return
+			}
+
+			if serveToken == "" {
+				serveToken = os.Getenv("KEYLIGHTCTL_TOKEN")
+			}
+			tokens := tokensConfig
+			if serveToken != "" {
+				tokens = append(tokens, server.TokenConfig{Name: "cli", Token: serveToken, Scope: server.ScopeReadWrite})
+				if err := server.ValidateTokens(tokens, lightsConfig, groupsConfig); err != nil {
+					fmt.Println(err)
+					return
+				}
+			}
+
+			auditLog := log.Default()
+			if serveAuditLog != "" {
+				file, err := os.OpenFile(serveAuditLog, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0o600)
+				if err != nil {
+					fmt.Println(err)
+					return
+				}
+				defer file.Close()
+				auditLog = log.New(file, "", log.LstdFlags)
+			}
+
+			slowSubscriber, err := server.ParseSlowSubscriberPolicy(serveSlowSubscriber)
+			if err != nil {
+				fmt.Println(err)
+				return
+			}
+
+			ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+			defer stop()
+
+			var (
+				schedules []schedule.Schedule
+				calendars []calendar.Calendar
+			)
+			if serveSchedules {
+				schedules = schedulesConfig
+				calendars = calendarsConfig
+			}
+
+			var (
+				m    *metrics.Metrics
+				opts []keylight.ControllerOption
+			)
+			if serveMetrics {
+				m = metrics.New(ToLights(lightsConfig))
+				opts = append(opts, keylight.WithObserver(m))
+			}
+
+			srv := server.New(server.Config{
+				Lights:         lightsConfig,
+				Groups:         groupsConfig,
+				Scenes:         scenesConfig,
+				Refresh:        serveRefresh,
+				EventBuffer:    serveEventBuffer,
+				SlowSubscriber: slowSubscriber,
+				Tokens:         tokens,
+				AuditLog:       auditLog,
+				Metrics:        m,
+				Schedules:      schedules,
+				Calendars:      calendars,
+			}, keylight.NewController(opts...))
+
+			runDone := make(chan struct{})
+			go func() {
+				defer close(runDone)
+				srv.Run(ctx)
+			}()
+			defer func() { <-runDone }()
+
+			httpServer := &http.Server{
+				Addr:              serveListen,
+				Handler:           srv.Handler(),
+				ReadHeaderTimeout: 10 * time.Second,
+				// Cancel long-lived event streams on shutdown.
+				BaseContext: func(net.Listener) context.Context { return ctx },
+				ConnContext: server.ConnContext,
+			}
+
+			go func() {
+				<-ctx.Done()
+				shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+				defer cancel()
+				httpServer.Shutdown(shutdownCtx)
+			}()
+
+			listeners, err := serveListeners(len(tokens) > 0)
+			if err != nil {
+				log.Fatalf("Server failed: %v", err)
+			}
+
+			errs := make(chan error, len(listeners))
+			for _, listener := range listeners {
+				go func(listener net.Listener) {
+					errs <- httpServer.Serve(listener)
+				}(listener)
+			}
+			for range listeners {
+				if err := <-errs; err != nil && !errors.Is(err, http.ErrServerClosed) {
+					log.Fatalf("Server failed: %v", err)
+				}
+			}
+		},
+	}
+)
+
+func serveListeners(authenticated bool) ([]net.Listener, error) {
+	var listeners []net.Listener
+
+	if serveListen != "" {
+		listener, err := net.Listen("tcp", serveListen)
+		if err != nil {
+			return nil, err
+		}
+
+		scheme := "http"
+		if serveTLSCert != "" {
+			cert, err := tls.LoadX509KeyPair(serveTLSCert, serveTLSKey)
+			if err != nil {
+				listener.Close()
+				return nil, fmt.Errorf("loading TLS certificate: %w", err)
+			}
+			listener = tls.NewListener(listener, &tls.Config{
+				Certificates: []tls.Certificate{cert},
+				MinVersion:   tls.VersionTLS12,
+			})
+			scheme = "https"
+		}
+
+		if !authenticated && !isLoopback(listener.Addr()) {
+			if !serveInsecure {
+				listener.Close()
+				return nil, fmt.Errorf("refusing to listen on %s without a token, anyone on the network could control the lights; configure a token or pass --insecure", listener.Addr())
+			}
+			log.Printf("Warning: listening on %s without a token, anyone on the network can control the lights", listener.Addr())
+		}
+		log.Printf("Listening on %s://%s", scheme, listener.Addr())
+		listeners = append(listeners, listener)
+	}
+
+	if serveSocket != "" {
+		listener, err := listenSocket(serveSocket)
+		if err != nil {
+			return nil, err
+		}
+		log.Printf("Listening on %s", serveSocket)
+		listeners = append(listeners, listener)
+	}
+
+	if len(listeners) == 0 {
+		return nil, errors.New("neither --listen nor --socket is set")
+	}
+	return listeners, nil
+}
+
+func isLoopback(addr net.Addr) bool {
+	tcpAddr, ok := addr.(*net.TCPAddr)
+	return ok && tcpAddr.IP.IsLoopback()
+}
+
+// listenSocket listens on a Unix socket that only the current user can
+// access, replacing a stale socket file left behind by a previous daemon.
+func listenSocket(path string) (net.Listener, error) {
+	if daemonListening(path) {
+		return nil, fmt.Errorf("another daemon is already listening on %s", path)
+	}
+	if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, err
+	}
+
+	listener, err := net.Listen("unix", path)
+	if err != nil {
+		return nil, err
+	}
+	if err := os.Chmod(path, 0o600); err != nil {
+		listener.Close()
+		return nil, err
+	}
+	return listener, nil
+}
+
+func init() {
+	serveCmd.Flags().StringVar(&serveListen, "listen", "127.0.0.1:8787", "Address to listen on")
+	serveCmd.Flags().StringVar(&serveSocket, "socket", api.DefaultSocketPath(), "Unix socket to listen on for local clients (empty to disable)")
+	serveCmd.Flags().DurationVar(&serveRefresh, "refresh", 5*time.Second, "Interval at which the state of every light is refreshed")
+	serveCmd.Flags().StringVar(&serveToken, "token", "", "Additional read-write bearer token accepted from clients not using the Unix socket (default $KEYLIGHTCTL_TOKEN)")
+	serveCmd.Flags().StringVar(&serveTLSCert, "tls-cert", "", "TLS certificate file; serves HTTPS when set")
+	serveCmd.Flags().StringVar(&serveTLSKey, "tls-key", "", "TLS private key file")
+	serveCmd.Flags().BoolVar(&serveInsecure, "insecure", false, "Allow listening on a non-loopback address without a token")
+	serveCmd.MarkFlagsRequiredTogether("tls-cert", "tls-key")
+	serveCmd.Flags().StringVar(&serveAuditLog, "audit-log", "", "File to append audit log entries to (default standard error)")
+	serveCmd.Flags().BoolVar(&serveMetrics, "metrics", false, "Serve Prometheus metrics on /metrics")
+	serveCmd.Flags().BoolVar(&serveSchedules, "schedules", true, "Run the configured schedules and calendars")
+	serveCmd.Flags().IntVar(&serveEventBuffer, "event-buffer", 64, "Number of events buffered per event stream subscriber")
+	serveCmd.Flags().StringVar(&serveSlowSubscriber, "slow-subscriber", string(server.DisconnectSlowSubscribers), "What to do when a subscriber's buffer is full: disconnect or drop")
+
+	rootCmd.AddCommand(serveCmd)
+}
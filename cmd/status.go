@@ -2,39 +2,46 @@ package cmd
 
 import (
 	"fmt"
+	"os"
 
 	"github.com/eckertalex/keylightctl/internal/keylight"
 	"github.com/spf13/cobra"
 )
 
 var (
-	statusLightName string
-	statusCmd       = &cobra.Command{
+	statusSelector lightSelector
+	statusFormat   string
+	statusCmd      = &cobra.Command{
 		Use:   "status",
 		Short: "Get the current status of all configured lights",
 		Run: func(cmd *cobra.Command, args []string) {
-			if statusLightName != "" {
-				lightConfig := FindLightByName(lightsConfig, statusLightName)
-
-				if lightConfig == nil {
-					availableLights := GetAvailableLightNames(lightsConfig)
-					fmt.Printf("Light '%s' not found. Available lights: %s\n", statusLightName, availableLights)
-					return
-				}
-
-				lights := ToLights([]keylight.LightConfig{*lightConfig})
-				GetLightsSettings(lights)
+			lightConfigs, err := statusSelector.resolve(lightsConfig, groupsConfig)
+			if err != nil {
+				fmt.Println(err)
 				return
 			}
 
-			lights := ToLights(lightsConfig)
-			GetLightsSettings(lights)
+			lights := ToLights(lightConfigs)
+			printLightsStatus(lights)
 		},
 	}
 )
 
+func printLightsStatus(lights []keylight.Light) {
+	if statusFormat == "" {
+		GetLightsSettings(lights)
+		return
+	}
+
+	if err := formatLightsSettings(os.Stdout, lights, statusFormat); err != nil {
+		fmt.Fprintf(os.Stderr, "Invalid format: %v\n", err)
+		os.Exit(1)
+	}
+}
+
 func init() {
-	statusCmd.Flags().StringVarP(&statusLightName, "light", "l", "", "Specify the light name")
+	addSelectorFlags(statusCmd, &statusSelector)
+	statusCmd.Flags().StringVarP(&statusFormat, "format", "f", "", `Go template applied to each light's status, or "waybar" for waybar JSON`)
 
 	rootCmd.AddCommand(statusCmd)
 }
@@ -0,0 +1,76 @@
+package cmd
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/eckertalex/keylightctl/internal/focus"
+	"github.com/eckertalex/keylightctl/internal/keylight"
+	"github.com/spf13/cobra"
+)
+
+var (
+	timerSelector        lightSelector
+	timerBreak           time.Duration
+	timerCycles          int
+	timerBreakBrightness int
+	timerCmd             = &cobra.Command{
+		Use:   "timer [focus duration]",
+		Short: "Run a focus timer that signals focus and breaks through the lights",
+		Args:  cobra.MaximumNArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			duration := 25 * time.Minute
+			if len(args) == 1 {
+				var err error
+				if duration, err = time.ParseDuration(args[0]); err != nil {
+					fmt.Printf("Invalid focus duration: %v\n", err)
+					return
+				}
+			}
+			if duration <= 0 {
+				fmt.Println("Invalid focus duration: must be positive")
+				return
+			}
+			if timerBreak < 0 {
+				fmt.Println("Invalid break: must not be negative")
+				return
+			}
+			if timerCycles <= 0 {
+				fmt.Println("Invalid cycles: must be positive")
+				return
+			}
+			if err := keylight.ValidateBrightness(timerBreakBrightness); err != nil {
+				fmt.Printf("Invalid break brightness: %v\n", err)
+				return
+			}
+
+			lightConfigs, err := timerSelector.resolve(lightsConfig, groupsConfig)
+			if err != nil {
+				fmt.Println(err)
+				return
+			}
+
+			signals, err := focus.NewSignals(freshClient(), ToLights(lightConfigs), timerBreakBrightness)
+			if signals == nil {
+				fmt.Printf("Failed to read the lights: %v\n", err)
+				return
+			}
+			if err != nil {
+				fmt.Printf("Skipping lights that cannot be read: %v\n", err)
+			}
+
+			if err := focus.Run(focus.Plan(duration, timerBreak, timerCycles), signals); err != nil {
+				fmt.Println(err)
+			}
+		},
+	}
+)
+
+func init() {
+	addSelectorFlags(timerCmd, &timerSelector)
+	timerCmd.Flags().DurationVar(&timerBreak, "break", 5*time.Minute, "Duration of the breaks between focus cycles")
+	timerCmd.Flags().IntVar(&timerCycles, "cycles", 4, "Number of focus cycles")
+	timerCmd.Flags().IntVar(&timerBreakBrightness, "break-brightness", 10, "Brightness percentage (0-100) during breaks, 0 switches the lights off")
+
+	rootCmd.AddCommand(timerCmd)
+}
@@ -0,0 +1,71 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/eckertalex/keylightctl/internal/keylight"
+	"github.com/spf13/cobra"
+)
+
+var (
+	toggleSelector lightSelector
+	toggleMode     string
+	toggleCmd      = &cobra.Command{
+		Use:   "toggle",
+		Short: "Toggle the lights on or off",
+		Run: func(cmd *cobra.Command, args []string) {
+			mode, err := keylight.ParseToggleMode(toggleMode)
+			if err != nil {
+				fmt.Println(err)
+				return
+			}
+
+			lightConfigs, err := toggleSelector.resolve(lightsConfig, groupsConfig)
+			if err != nil {
+				fmt.Println(err)
+				return
+			}
+
+			controller := lightClient()
+
+			var (
+				lights []keylight.Light
+				on     []bool
+			)
+			selected := ToLights(lightConfigs)
+			for i, result := range collectLightResults(selected, controller.GetLight) {
+				if result.err != nil || len(result.status.Lights) == 0 {
+					msg := "empty status"
+					if result.err != nil {
+						msg = describeError(result.err)
+					}
+					fmt.Printf("Status of light \"%s\": Error: %s\n", result.name, msg)
+					continue
+				}
+				lights = append(lights, selected[i])
+				on = append(on, result.status.Lights[0].On == 1)
+			}
+
+			next := keylight.Toggle(mode, on)
+			targets := make(map[string]int, len(lights))
+			for i, light := range lights {
+				targets[light.IP] = 0
+				if next[i] {
+					targets[light.IP] = 1
+				}
+			}
+
+			toggleOperation := func(ip string) (*keylight.LightStatus, error) {
+				return controller.UpdateLight(ip, keylight.LightDetail{On: targets[ip]})
+			}
+			processLightOperation(lights, toggleOperation, "Update")
+		},
+	}
+)
+
+func init() {
+	addSelectorFlags(toggleCmd, &toggleSelector)
+	toggleCmd.Flags().StringVar(&toggleMode, "mode", string(keylight.ToggleAnyOnTurnsAllOff), "Toggle policy: any-on-turns-all-off or independent")
+
+	rootCmd.AddCommand(toggleCmd)
+}
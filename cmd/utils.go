@@ -6,10 +6,10 @@ import (
 	"fmt"
 	"io"
 	"net"
-	"strings"
 	"sync"
 	"time"
 
+	"github.com/eckertalex/keylightctl/api"
 	"github.com/eckertalex/keylightctl/internal/keylight"
 )
 
@@ -29,65 +29,58 @@ func Spinner(done <-chan struct{}) {
 	}
 }
 
-func ValidateBrightness(brightness int) error {
-	if brightness < 0 || brightness > 100 {
-		return fmt.Errorf("brightness must be between 0 and 100")
-	}
-	return nil
-}
+type lightOperation func(ip string) (*keylight.LightStatus, error)
 
-func ValidateTemperature(temperature int) error {
-	if temperature < 2900 || temperature > 7000 {
-		return fmt.Errorf("temperature must be between 2900K and 7000K")
-	}
-	return nil
+type lightResult struct {
+	err    error
+	status *keylight.LightStatus
+	name   string
 }
 
-type lightOperation func(ip string) (*keylight.LightStatus, error)
-
-func processLightOperation(lights []keylight.Light, operation lightOperation, operationName string) {
+func runLightOperation(lights []keylight.Light, operation lightOperation) <-chan lightResult {
 	var wg sync.WaitGroup
-	results := make(chan struct {
-		err    error
-		status *keylight.LightStatus
-		name   string
-	}, len(lights))
-
-	done := make(chan struct{})
-	go Spinner(done)
+	results := make(chan lightResult, len(lights))
 
 	for _, light := range lights {
 		wg.Add(1)
 		go func(light keylight.Light) {
 			defer wg.Done()
 			status, err := operation(light.IP)
-			results <- struct {
-				err    error
-				status *keylight.LightStatus
-				name   string
-			}{err, status, light.Name}
+			results <- lightResult{err, status, light.Name}
 		}(light)
 	}
 
 	go func() {
 		wg.Wait()
 		close(results)
-		close(done)
 	}()
 
-	for result := range results {
+	return results
+}
+
+// collectLightResults runs operation against every light and returns the
+// results in the same order as lights.
+func collectLightResults(lights []keylight.Light, operation lightOperation) []lightResult {
+	byName := make(map[string]lightResult, len(lights))
+	for result := range runLightOperation(lights, operation) {
+		byName[result.name] = result
+	}
+
+	ordered := make([]lightResult, 0, len(lights))
+	for _, light := range lights {
+		ordered = append(ordered, byName[light.Name])
+	}
+	return ordered
+}
+
+func processLightOperation(lights []keylight.Light, operation lightOperation, operationName string) {
+	done := make(chan struct{})
+	go Spinner(done)
+	defer close(done)
+
+	for result := range runLightOperation(lights, operation) {
 		if result.err != nil {
-			msg := "unknown error"
-			switch {
-			case errors.Is(result.err, context.DeadlineExceeded) ||
-				errors.Is(result.err, context.Canceled):
-				msg = "timeout while connecting"
-			case errors.Is(result.err, io.EOF):
-				msg = "connection closed unexpectedly"
-			case isConnectionError(result.err):
-				msg = "failed to connect"
-			}
-			fmt.Printf("\r%s of light \"%s\": Error: %s\n", operationName, result.name, msg)
+			fmt.Printf("\r%s of light \"%s\": Error: %s\n", operationName, result.name, describeError(result.err))
 			continue
 		}
 
@@ -102,6 +95,27 @@ func processLightOperation(lights []keylight.Light, operation lightOperation, op
 	}
 }
 
+func describeError(err error) string {
+	var (
+		daemonErr *daemonError
+		statusErr *api.StatusError
+	)
+	switch {
+	case errors.As(err, &daemonErr):
+		return daemonErr.msg
+	case errors.As(err, &statusErr):
+		return statusErr.Message
+	case errors.Is(err, context.DeadlineExceeded) ||
+		errors.Is(err, context.Canceled):
+		return "timeout while connecting"
+	case errors.Is(err, io.EOF):
+		return "connection closed unexpectedly"
+	case isConnectionError(err):
+		return "failed to connect"
+	}
+	return "unknown error"
+}
+
 func isConnectionError(err error) bool {
 	var netErr *net.OpError
 	return errors.As(err, &netErr)
@@ -115,35 +129,18 @@ func formatOnOff(on int) string {
 }
 
 func GetLightsSettings(lights []keylight.Light) {
-	controller := keylight.NewController()
+	controller := lightClient()
 	processLightOperation(lights, controller.GetLight, "Status")
 }
 
 func UpdateLightsSettings(lights []keylight.Light, settings keylight.LightDetail) {
-	controller := keylight.NewController()
+	controller := lightClient()
 	updateOperation := func(ip string) (*keylight.LightStatus, error) {
 		return controller.UpdateLight(ip, settings)
 	}
 	processLightOperation(lights, updateOperation, "Update")
 }
 
-func FindLightByName(lights []keylight.LightConfig, name string) *keylight.LightConfig {
-	for i := range lights {
-		if lights[i].Name == name {
-			return &lights[i]
-		}
-	}
-	return nil
-}
-
-func GetAvailableLightNames(lights []keylight.LightConfig) string {
-	var names []string
-	for _, light := range lights {
-		names = append(names, light.Name)
-	}
-	return strings.Join(names, ", ")
-}
-
 func ToLights(lightsConfig []keylight.LightConfig) []keylight.Light {
 	var lights []keylight.Light
 	for _, lightConfig := range lightsConfig {
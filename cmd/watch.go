@@ -0,0 +1,142 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/signal"
+	"time"
+
+	"github.com/eckertalex/keylightctl/internal/keylight"
+	"github.com/eckertalex/keylightctl/internal/watch"
+	"github.com/spf13/cobra"
+)
+
+var (
+	watchSelector lightSelector
+	watchInterval time.Duration
+	watchOutput   string
+	watchCmd      = &cobra.Command{
+		Use:   "watch",
+		Short: "Stream changes to the state of the lights",
+		Run: func(cmd *cobra.Command, args []string) {
+			if watchOutput != "text" && watchOutput != "json" {
+				fmt.Printf("Invalid output '%s', expected text or json\n", watchOutput)
+				return
+			}
+			if watchInterval <= 0 {
+				fmt.Println("Invalid interval: must be positive")
+				return
+			}
+
+			lightConfigs, err := watchSelector.resolve(lightsConfig, groupsConfig)
+			if err != nil {
+				fmt.Println(err)
+				return
+			}
+
+			ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+			defer stop()
+
+			encoder := json.NewEncoder(os.Stdout)
+			watcher := watch.New(lightClient(), ToLights(lightConfigs), watchInterval)
+			watcher.Run(ctx, func(event watch.Event) {
+				if watchOutput == "json" {
+					encoder.Encode(toWatchEvent(event))
+					return
+				}
+				printWatchEvent(event)
+			})
+		},
+	}
+)
+
+type watchState struct {
+	On          bool `json:"on"`
+	Brightness  int  `json:"brightness"`
+	Temperature int  `json:"temperature"`
+}
+
+type watchEvent struct {
+	Time     time.Time   `json:"time"`
+	Type     string      `json:"type"`
+	Light    string      `json:"light"`
+	State    *watchState `json:"state,omitempty"`
+	Previous *watchState `json:"previous,omitempty"`
+	Changes  []string    `json:"changes,omitempty"`
+	Error    string      `json:"error,omitempty"`
+}
+
+func toWatchState(detail keylight.LightDetail) *watchState {
+	state := &watchState{On: detail.On == 1, Brightness: detail.Brightness}
+	if detail.Temperature != 0 {
+		state.Temperature = keylight.MiredToKelvin(detail.Temperature)
+	}
+	return state
+}
+
+func toWatchEvent(event watch.Event) watchEvent {
+	out := watchEvent{
+		Time:  event.Time,
+		Type:  string(event.Type),
+		Light: event.Light.Name,
+	}
+
+	switch event.Type {
+	case watch.EventOffline:
+		out.Error = describeError(event.Err)
+	case watch.EventOnline:
+		out.State = toWatchState(event.Current)
+	case watch.EventChanged:
+		out.State = toWatchState(event.Current)
+		out.Previous = toWatchState(event.Previous)
+		out.Changes = changedFields(event.Previous, event.Current)
+	}
+	return out
+}
+
+func changedFields(prev, curr keylight.LightDetail) []string {
+	var fields []string
+	if prev.On != curr.On {
+		fields = append(fields, "power")
+	}
+	if prev.Brightness != curr.Brightness {
+		fields = append(fields, "brightness")
+	}
+	if prev.Temperature != curr.Temperature {
+		fields = append(fields, "temperature")
+	}
+	return fields
+}
+
+func printWatchEvent(event watch.Event) {
+	prefix := fmt.Sprintf("%s %s", event.Time.Format(time.TimeOnly), event.Light.Name)
+	switch event.Type {
+	case watch.EventOffline:
+		fmt.Printf("%s offline: %s\n", prefix, describeError(event.Err))
+	case watch.EventOnline:
+		state := toWatchState(event.Current)
+		fmt.Printf("%s online: %s, %d%%, %dK\n", prefix, formatOnOff(event.Current.On), state.Brightness, state.Temperature)
+	case watch.EventChanged:
+		prev, curr := toWatchState(event.Previous), toWatchState(event.Current)
+		for _, field := range changedFields(event.Previous, event.Current) {
+			switch field {
+			case "power":
+				fmt.Printf("%s power: %s -> %s\n", prefix, formatOnOff(event.Previous.On), formatOnOff(event.Current.On))
+			case "brightness":
+				fmt.Printf("%s brightness: %d%% -> %d%%\n", prefix, prev.Brightness, curr.Brightness)
+			case "temperature":
+				fmt.Printf("%s temperature: %dK -> %dK\n", prefix, prev.Temperature, curr.Temperature)
+			}
+		}
+	}
+}
+
+func init() {
+	addSelectorFlags(watchCmd, &watchSelector)
+	watchCmd.Flags().DurationVar(&watchInterval, "interval", 2*time.Second, "Polling interval")
+	watchCmd.Flags().StringVarP(&watchOutput, "output", "o", "text", "Output format: text or json (one event per line)")
+
+	rootCmd.AddCommand(watchCmd)
+}
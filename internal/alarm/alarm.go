@@ -0,0 +1,235 @@
+// Package alarm wakes up with the lights, ramping them up like a sunrise
+// that ends at the alarm time.
+package alarm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/eckertalex/keylightctl/internal/clock"
+	"github.com/eckertalex/keylightctl/internal/keylight"
+	"github.com/eckertalex/keylightctl/internal/scene"
+)
+
+const (
+	DefaultDuration    = 30 * time.Minute
+	DefaultBrightness  = 100
+	DefaultTemperature = 5000
+
+	// warmest is the temperature a sunrise starts at, in Kelvin.
+	warmest = 2900
+	// minBrightness is the brightness a sunrise starts at.
+	minBrightness = 1
+	// maxInterval bounds the time between updates of a light.
+	maxInterval = 5 * time.Second
+	// maxSleep bounds how long a sunrise sleeps at once while waiting to
+	// begin, so that it notices jumps of the wall clock, for example after a
+	// suspend, in time.
+	maxSleep = time.Minute
+)
+
+// Next returns the first time after now the wall clock reads at, an "HH:MM"
+// time.
+func Next(at string, now time.Time) (time.Time, error) {
+	t, err := time.Parse("15:04", at)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid time '%s': expected HH:MM", at)
+	}
+
+	next := time.Date(now.Year(), now.Month(), now.Day(), t.Hour(), t.Minute(), 0, 0, now.Location())
+	if !next.After(now) {
+		next = time.Date(now.Year(), now.Month(), now.Day()+1, t.Hour(), t.Minute(), 0, 0, now.Location())
+	}
+	return next, nil
+}
+
+// Sunrise ramps lights from off to the brightness and temperature of their
+// target over Duration, ending at End. Targets that leave brightness or
+// temperature unset end at DefaultBrightness or DefaultTemperature, and
+// targets that switch their light off are skipped.
+type Sunrise struct {
+	Client   keylight.Client
+	Targets  []scene.Target
+	End      time.Time
+	Duration time.Duration
+	Clock    clock.Clock
+	// Interval is the time between updates of a light.
+	Interval time.Duration
+}
+
+func NewSunrise(client keylight.Client, targets []scene.Target, end time.Time, duration time.Duration) *Sunrise {
+	return &Sunrise{
+		Client:   client,
+		Targets:  targets,
+		End:      end,
+		Duration: duration,
+		Clock:    clock.System,
+		Interval: min(max(duration/300, 100*time.Millisecond), maxInterval),
+	}
+}
+
+// Start is when the lights switch on.
+func (s *Sunrise) Start() time.Time {
+	return s.End.Add(-s.Duration)
+}
+
+// Run waits for the sunrise to begin and ramps up every light in parallel.
+// A light that is changed by someone else during the ramp is left alone
+// from then on. Run returns once every light has reached its target or was
+// left alone, or ctx is done.
+func (s *Sunrise) Run(ctx context.Context) {
+	if !s.sleepUntil(ctx, s.Start()) {
+		return
+	}
+
+	var wg sync.WaitGroup
+	for _, target := range s.Targets {
+		if target.State.On != nil && !*target.State.On {
+			continue
+		}
+		wg.Add(1)
+		go func(target scene.Target) {
+			defer wg.Done()
+			s.runLight(ctx, target)
+		}(target)
+	}
+	wg.Wait()
+}
+
+// sleepUntil waits for the wall clock to reach t and reports whether it did
+// before ctx was done.
+func (s *Sunrise) sleepUntil(ctx context.Context, t time.Time) bool {
+	for {
+		// Strip the monotonic clock reading, which does not advance while
+		// the machine is suspended.
+		wait := t.Sub(s.Clock.Now().Round(0))
+		if wait <= 0 {
+			return true
+		}
+
+		select {
+		case <-ctx.Done():
+			return false
+		case <-s.Clock.After(min(wait, maxSleep)):
+		}
+	}
+}
+
+func (s *Sunrise) runLight(ctx context.Context, target scene.Target) {
+	light := target.Light
+	var (
+		// expected is the state last set, as reported by the light.
+		expected *keylight.LightDetail
+		failing  bool
+	)
+
+	for {
+		detail := s.frame(target.State, s.progress(s.Clock.Now().Round(0)))
+
+		err := func() error {
+			if expected != nil {
+				current, err := s.get(light)
+				if err != nil {
+					return err
+				}
+				if current != *expected {
+					return errAdjusted
+				}
+				if current == detail {
+					return nil
+				}
+			}
+
+			status, err := s.Client.UpdateLight(light.IP, detail)
+			if err != nil {
+				return err
+			}
+			if len(status.Lights) > 0 {
+				detail = status.Lights[0]
+			}
+			expected = &detail
+			return nil
+		}()
+
+		switch {
+		case errors.Is(err, errAdjusted):
+			log.Printf("Light '%s' was adjusted manually, cancelling its sunrise", light.Name)
+			return
+		case err != nil:
+			if !failing {
+				log.Printf("Light '%s' failed: %v", light.Name, err)
+			}
+			failing = true
+		default:
+			if failing {
+				log.Printf("Light '%s' is reachable again", light.Name)
+			}
+			failing = false
+			if !s.Clock.Now().Round(0).Before(s.End) && *expected == detail {
+				return
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-s.Clock.After(s.Interval):
+		}
+	}
+}
+
+var errAdjusted = errors.New("adjusted manually")
+
+// progress returns how far the sunrise is at t, between 0 and 1.
+func (s *Sunrise) progress(t time.Time) float64 {
+	if s.Duration <= 0 {
+		return 1
+	}
+	return min(max(float64(t.Sub(s.Start()))/float64(s.Duration), 0), 1)
+}
+
+// frame returns the state of a light at the given progress. Brightness
+// follows the inverse of the CIE lightness curve, so that it appears to rise
+// evenly, and temperature moves evenly in mired.
+func (s *Sunrise) frame(state scene.State, progress float64) keylight.LightDetail {
+	brightness, temperature := DefaultBrightness, DefaultTemperature
+	if state.Brightness != nil {
+		brightness = *state.Brightness
+	}
+	if state.Temperature != nil {
+		temperature = *state.Temperature
+	}
+
+	from, to := keylight.KelvinToMiredExact(warmest), keylight.KelvinToMiredExact(temperature)
+	return keylight.LightDetail{
+		On:          1,
+		Brightness:  minBrightness + int(math.Round(float64(max(brightness-minBrightness, 0))*luminance(progress))),
+		Temperature: from + int(math.Round(float64(to-from)*progress)),
+	}
+}
+
+// luminance returns the relative luminance that appears as bright as the
+// given lightness, both between 0 and 1.
+func luminance(lightness float64) float64 {
+	l := 100 * lightness
+	if l <= 8 {
+		return l / 903.3
+	}
+	return math.Pow((l+16)/116, 3)
+}
+
+func (s *Sunrise) get(light keylight.Light) (keylight.LightDetail, error) {
+	status, err := s.Client.GetLight(light.IP)
+	if err != nil {
+		return keylight.LightDetail{}, err
+	}
+	if len(status.Lights) == 0 {
+		return keylight.LightDetail{}, errors.New("empty status")
+	}
+	return status.Lights[0], nil
+}
@@ -0,0 +1,157 @@
+package alarm
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/eckertalex/keylightctl/internal/keylight"
+	"github.com/eckertalex/keylightctl/internal/keylighttest"
+	"github.com/eckertalex/keylightctl/internal/scene"
+)
+
+const ip = "10.0.0.1"
+
+var date = keylighttest.Date
+
+func intPtr(v int) *int {
+	return &v
+}
+
+func TestNext(t *testing.T) {
+	tests := []struct {
+		name string
+		at   string
+		now  time.Time
+		want time.Time
+	}{
+		{"later today", "07:00", date(10, 6, 59), date(10, 7, 0)},
+		{"at the time", "07:00", date(10, 7, 0), date(11, 7, 0)},
+		{"across midnight", "07:00", date(10, 23, 30), date(11, 7, 0)},
+		{"just after midnight", "00:10", date(31, 23, 50), time.Date(2026, time.February, 1, 0, 10, 0, 0, time.UTC)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Next(tt.at, tt.now)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !got.Equal(tt.want) {
+				t.Errorf("Next(%q, %s) = %s, want %s", tt.at, tt.now, got, tt.want)
+			}
+		})
+	}
+
+	if _, err := Next("7am", date(10, 6, 0)); err == nil {
+		t.Error("expected an error for an invalid time")
+	}
+}
+
+func TestProgressAndFrame(t *testing.T) {
+	s := &Sunrise{End: date(10, 7, 0), Duration: 10 * time.Minute}
+	state := scene.State{Brightness: intPtr(80), Temperature: intPtr(4000)}
+
+	tests := []struct {
+		name     string
+		at       time.Time
+		progress float64
+		frame    keylight.LightDetail
+		defaults keylight.LightDetail
+	}{
+		{"before", date(10, 6, 0), 0, keylight.LightDetail{On: 1, Brightness: 1, Temperature: 344}, keylight.LightDetail{On: 1, Brightness: 1, Temperature: 344}},
+		{"start", date(10, 6, 50), 0, keylight.LightDetail{On: 1, Brightness: 1, Temperature: 344}, keylight.LightDetail{On: 1, Brightness: 1, Temperature: 344}},
+		{"half", date(10, 6, 55), 0.5, keylight.LightDetail{On: 1, Brightness: 16, Temperature: 297}, keylight.LightDetail{On: 1, Brightness: 19, Temperature: 272}},
+		{"end", date(10, 7, 0), 1, keylight.LightDetail{On: 1, Brightness: 80, Temperature: 250}, keylight.LightDetail{On: 1, Brightness: 100, Temperature: 200}},
+		{"after", date(10, 8, 0), 1, keylight.LightDetail{On: 1, Brightness: 80, Temperature: 250}, keylight.LightDetail{On: 1, Brightness: 100, Temperature: 200}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			progress := s.progress(tt.at)
+			if progress != tt.progress {
+				t.Errorf("progress = %v, want %v", progress, tt.progress)
+			}
+			if got := s.frame(state, progress); got != tt.frame {
+				t.Errorf("frame = %+v, want %+v", got, tt.frame)
+			}
+			if got := s.frame(scene.State{}, progress); got != tt.defaults {
+				t.Errorf("frame without brightness and temperature = %+v, want %+v", got, tt.defaults)
+			}
+		})
+	}
+}
+
+func newTestSunrise(clock *keylighttest.Clock, lights *keylighttest.Lights) *Sunrise {
+	return &Sunrise{
+		Client: lights,
+		Targets: []scene.Target{{
+			Light: keylight.Light{Name: "Left", IP: ip},
+			State: scene.State{Brightness: intPtr(80), Temperature: intPtr(4000)},
+		}},
+		End:      date(10, 7, 0),
+		Duration: 10 * time.Minute,
+		Clock:    clock,
+		Interval: time.Minute,
+	}
+}
+
+func TestSunriseRun(t *testing.T) {
+	clock := keylighttest.NewClock(date(10, 6, 45))
+	lights := keylighttest.NewLights(map[string]keylight.LightDetail{ip: {}})
+	var times []time.Time
+	lights.AfterUpdate = func(string, keylight.LightDetail) { times = append(times, clock.Now()) }
+	s := newTestSunrise(clock, lights)
+
+	s.Run(context.Background())
+
+	updates := lights.Updates(ip)
+	if len(updates) != 11 {
+		t.Fatalf("got %d updates, want one per minute of the sunrise: %+v", len(updates), updates)
+	}
+	if !times[0].Equal(s.Start()) {
+		t.Errorf("first update at %s, want %s", times[0], s.Start())
+	}
+	for i := 1; i < len(updates); i++ {
+		prev, cur := updates[i-1], updates[i]
+		if cur.Brightness < prev.Brightness || cur.Temperature > prev.Temperature {
+			t.Errorf("update %d went back from %+v to %+v", i, prev, cur)
+		}
+	}
+	if want := (keylight.LightDetail{On: 1, Brightness: 80, Temperature: 250}); lights.Get(ip) != want {
+		t.Errorf("light ended at %+v, want %+v", lights.Get(ip), want)
+	}
+}
+
+func TestSunriseCancelledWhenAdjusted(t *testing.T) {
+	clock := keylighttest.NewClock(date(10, 6, 50))
+	lights := keylighttest.NewLights(map[string]keylight.LightDetail{ip: {}})
+	adjusted := keylight.LightDetail{On: 0, Brightness: 30, Temperature: 300}
+	lights.AfterUpdate = func(string, keylight.LightDetail) {
+		if len(lights.Updates(ip)) == 3 {
+			lights.Set(ip, adjusted)
+		}
+	}
+	s := newTestSunrise(clock, lights)
+
+	s.Run(context.Background())
+
+	if n := len(lights.Updates(ip)); n != 3 {
+		t.Errorf("got %d updates, want none after the light was adjusted", n)
+	}
+	if got := lights.Get(ip); got != adjusted {
+		t.Errorf("light is %+v, want it left at %+v", got, adjusted)
+	}
+}
+
+func TestSunriseCancelled(t *testing.T) {
+	clock := keylighttest.NewClock(date(10, 6, 0))
+	lights := keylighttest.NewLights(map[string]keylight.LightDetail{ip: {}})
+	s := newTestSunrise(clock, lights)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	s.Run(ctx)
+
+	if n := len(lights.Updates(ip)); n != 0 {
+		t.Errorf("got %d updates after cancellation", n)
+	}
+}
@@ -0,0 +1,216 @@
+package animation
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"slices"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/eckertalex/keylightctl/internal/keylight"
+	"github.com/eckertalex/keylightctl/internal/scene"
+)
+
+// Request is an update of a light at an offset into the timeline. Its state
+// holds every field the timeline has set for the light so far, so that a
+// late request can be skipped in favor of the next one.
+type Request struct {
+	At    time.Duration
+	Light keylight.Light
+	State scene.State
+}
+
+type point struct {
+	at     time.Duration
+	value  float64
+	easing Easing
+}
+
+// track is the keyframes of one field of a light, ordered by offset.
+type track []point
+
+// value returns the value of the track at t, or false before its first
+// keyframe.
+func (tr track) value(t time.Duration) (float64, bool) {
+	i := sort.Search(len(tr), func(i int) bool { return tr[i].at > t })
+	switch {
+	case i == 0:
+		return 0, false
+	case i == len(tr):
+		return tr[i-1].value, true
+	}
+
+	prev, next := tr[i-1], tr[i]
+	progress := float64(t-prev.at) / float64(next.at-prev.at)
+	return prev.value + (next.value-prev.value)*next.easing.apply(progress), true
+}
+
+// Compile samples the timeline every step and returns the requests needed to
+// play it once, ordered by offset and then by light. A light is only updated
+// when its state changes.
+func Compile(t Timeline, lights []keylight.LightConfig, groups []keylight.GroupConfig, step time.Duration) ([]Request, error) {
+	keyframes := slices.Clone(t.Keyframes)
+	slices.SortStableFunc(keyframes, func(a, b Keyframe) int { return cmp.Compare(a.At, b.At) })
+
+	var requests []Request
+	for _, light := range lights {
+		var on, brightness, temperature track
+		for _, k := range keyframes {
+			names := []string{k.Light}
+			if k.Group != "" {
+				var err error
+				if names, err = keylight.ExpandGroup(groups, k.Group); err != nil {
+					return nil, err
+				}
+			}
+			if !slices.Contains(names, light.Name) {
+				continue
+			}
+
+			if k.On != nil {
+				value := 0.0
+				if *k.On {
+					value = 1
+				}
+				on = append(on, point{at: k.At, value: value, easing: Step})
+			}
+			if k.Brightness != nil {
+				brightness = append(brightness, point{at: k.At, value: float64(*k.Brightness), easing: k.Easing})
+			}
+			if k.Temperature != nil {
+				temperature = append(temperature, point{at: k.At, value: float64(*k.Temperature), easing: k.Easing})
+			}
+		}
+
+		var last string
+		for _, at := range sampleTimes(step, on, brightness, temperature) {
+			var state scene.State
+			if v, ok := on.value(at); ok {
+				state.On = ptr(v == 1)
+			}
+			if v, ok := brightness.value(at); ok {
+				state.Brightness = ptr(int(math.Round(v)))
+			}
+			if v, ok := temperature.value(at); ok {
+				state.Temperature = ptr(int(math.Round(v)))
+			}
+
+			if s := state.String(); s != last && s != "unchanged" {
+				requests = append(requests, Request{At: at, Light: light.Light, State: state})
+				last = s
+			}
+		}
+	}
+
+	sort.SliceStable(requests, func(i, j int) bool { return requests[i].At < requests[j].At })
+	return requests, nil
+}
+
+// sampleTimes returns the offsets of every keyframe of the tracks, and every
+// step in between.
+func sampleTimes(step time.Duration, tracks ...track) []time.Duration {
+	var first, last time.Duration = -1, 0
+	set := make(map[time.Duration]bool)
+	for _, tr := range tracks {
+		for _, p := range tr {
+			set[p.at] = true
+			if first < 0 || p.at < first {
+				first = p.at
+			}
+			last = max(last, p.at)
+		}
+	}
+	if first < 0 {
+		return nil
+	}
+	for at := first; at < last; at += step {
+		set[at] = true
+	}
+
+	times := make([]time.Duration, 0, len(set))
+	for at := range set {
+		times = append(times, at)
+	}
+	sort.Slice(times, func(i, j int) bool { return times[i] < times[j] })
+	return times
+}
+
+// Play runs the requests the given number of times, or until ctx is done if
+// loop is set. Every light is played in parallel. Requests are timed from
+// the start, and a request that is due while a later one for the same light
+// is already due is skipped, so that slow lights catch up instead of
+// drifting.
+func Play(ctx context.Context, client keylight.Client, requests []Request, duration time.Duration, repeat int, loop bool) error {
+	byLight := make(map[string][]Request)
+	var lights []keylight.Light
+	for _, r := range requests {
+		if _, ok := byLight[r.Light.Name]; !ok {
+			lights = append(lights, r.Light)
+		}
+		byLight[r.Light.Name] = append(byLight[r.Light.Name], r)
+	}
+
+	start := time.Now()
+	var (
+		wg   sync.WaitGroup
+		mu   sync.Mutex
+		errs []error
+	)
+	for _, light := range lights {
+		wg.Add(1)
+		go func(light keylight.Light) {
+			defer wg.Done()
+			if err := playLight(ctx, client, light, byLight[light.Name], start, duration, repeat, loop); err != nil {
+				mu.Lock()
+				errs = append(errs, fmt.Errorf("%s: %w", light.Name, err))
+				mu.Unlock()
+			}
+		}(light)
+	}
+	wg.Wait()
+
+	return errors.Join(errs...)
+}
+
+func playLight(ctx context.Context, client keylight.Client, light keylight.Light, requests []Request, start time.Time, duration time.Duration, repeat int, loop bool) error {
+	status, err := client.GetLight(light.IP)
+	if err != nil {
+		return err
+	}
+	if len(status.Lights) == 0 {
+		return errors.New("empty status")
+	}
+	current := status.Lights[0]
+
+	for i := 0; loop || i < repeat; i++ {
+		offset := start.Add(time.Duration(i) * duration)
+		for j, r := range requests {
+			if j+1 < len(requests) && !time.Now().Before(offset.Add(requests[j+1].At)) {
+				continue
+			}
+
+			select {
+			case <-ctx.Done():
+				return nil
+			case <-time.After(time.Until(offset.Add(r.At))):
+			}
+
+			status, err := client.UpdateLight(light.IP, r.State.Detail(current))
+			if err != nil {
+				return err
+			}
+			if len(status.Lights) > 0 {
+				current = status.Lights[0]
+			}
+		}
+	}
+	return nil
+}
+
+func ptr[T any](v T) *T {
+	return &v
+}
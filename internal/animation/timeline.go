@@ -0,0 +1,141 @@
+// Package animation plays timelines of keyframes on the lights.
+package animation
+
+import (
+	"fmt"
+	"math"
+	"reflect"
+	"slices"
+	"time"
+
+	"github.com/eckertalex/keylightctl/internal/keylight"
+	"github.com/eckertalex/keylightctl/internal/scene"
+	"github.com/mitchellh/mapstructure"
+	"github.com/spf13/viper"
+)
+
+type Easing string
+
+const (
+	Linear    Easing = "linear"
+	EaseIn    Easing = "ease-in"
+	EaseOut   Easing = "ease-out"
+	EaseInOut Easing = "ease-in-out"
+	// Step holds the previous value until the keyframe is reached.
+	Step Easing = "step"
+)
+
+var Easings = []Easing{Linear, EaseIn, EaseOut, EaseInOut, Step}
+
+// apply maps linear progress between two keyframes, between 0 and 1, to
+// eased progress.
+func (e Easing) apply(p float64) float64 {
+	switch e {
+	case EaseIn:
+		return p * p
+	case EaseOut:
+		return 1 - (1-p)*(1-p)
+	case EaseInOut:
+		if p < 0.5 {
+			return 2 * p * p
+		}
+		return 1 - math.Pow(-2*p+2, 2)/2
+	case Step:
+		if p < 1 {
+			return 0
+		}
+		return 1
+	}
+	return p
+}
+
+// Keyframe moves a light, or every light of a group, to a state at a time
+// offset. Brightness and temperature are interpolated from the light's
+// previous keyframe setting them, using the keyframe's easing. Power
+// switches at the keyframe.
+type Keyframe struct {
+	At               time.Duration `mapstructure:"at"`
+	scene.LightState `mapstructure:",squash"`
+	Easing           Easing `mapstructure:"easing"`
+}
+
+type Timeline struct {
+	// Repeat is how many times the timeline is played. Loop plays it until
+	// cancelled.
+	Repeat    int        `mapstructure:"repeat"`
+	Loop      bool       `mapstructure:"loop"`
+	Keyframes []Keyframe `mapstructure:"keyframes"`
+}
+
+// Duration is the offset of the last keyframe.
+func (t Timeline) Duration() time.Duration {
+	var d time.Duration
+	for _, k := range t.Keyframes {
+		d = max(d, k.At)
+	}
+	return d
+}
+
+// Load reads a timeline from a JSON or TOML file, depending on its
+// extension, and validates it.
+func Load(path string, lights []keylight.LightConfig, groups []keylight.GroupConfig) (*Timeline, error) {
+	v := viper.New()
+	v.SetConfigFile(path)
+	if err := v.ReadInConfig(); err != nil {
+		return nil, err
+	}
+
+	var t Timeline
+	hook := mapstructure.ComposeDecodeHookFunc(rejectNumericDurations, mapstructure.StringToTimeDurationHookFunc())
+	if err := v.Unmarshal(&t, viper.DecodeHook(hook)); err != nil {
+		return nil, err
+	}
+	if err := Validate(&t, lights, groups); err != nil {
+		return nil, err
+	}
+	return &t, nil
+}
+
+// rejectNumericDurations refuses numbers for durations, which would otherwise
+// be taken as nanoseconds: an offset of 3 is meant as "3s".
+func rejectNumericDurations(from, to reflect.Type, data any) (any, error) {
+	if to == reflect.TypeOf(time.Duration(0)) && from.Kind() != reflect.String {
+		return nil, fmt.Errorf("duration %v must be a string with a unit, like \"%vs\"", data, data)
+	}
+	return data, nil
+}
+
+// Validate checks that the timeline has keyframes at non-negative offsets,
+// each for a configured light or group with a valid state and easing. A
+// missing easing defaults to linear and a missing repeat count to 1.
+func Validate(t *Timeline, lights []keylight.LightConfig, groups []keylight.GroupConfig) error {
+	if len(t.Keyframes) == 0 {
+		return fmt.Errorf("timeline without keyframes")
+	}
+	if t.Repeat == 0 {
+		t.Repeat = 1
+	}
+	if t.Repeat < 0 {
+		return fmt.Errorf("repeat must be positive")
+	}
+	if t.Loop && t.Duration() == 0 {
+		return fmt.Errorf("cannot loop a timeline without duration")
+	}
+
+	for i := range t.Keyframes {
+		k := &t.Keyframes[i]
+		if k.At < 0 {
+			return fmt.Errorf("keyframe %d: offset must not be negative", i+1)
+		}
+		if err := scene.ValidateEntry(k.LightState, lights, groups); err != nil {
+			return fmt.Errorf("keyframe %d: %w", i+1, err)
+		}
+		if k.Easing == "" {
+			k.Easing = Linear
+		}
+		if !slices.Contains(Easings, k.Easing) {
+			return fmt.Errorf("keyframe %d: unknown easing '%s', expected one of %v", i+1, k.Easing, Easings)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,112 @@
+// Package calendar applies scenes around the events of local iCalendar
+// files, such as those synced by vdirsyncer.
+package calendar
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/eckertalex/keylightctl/internal/scene"
+)
+
+// Calendar applies a scene ahead of the matching events of an iCalendar file
+// or directory, and reverts the lights after they end.
+type Calendar struct {
+	Name string `mapstructure:"name"`
+	// Path is an .ics file or a directory searched for .ics files.
+	Path  string `mapstructure:"path"`
+	Scene string `mapstructure:"scene"`
+	// Before is how long before an event the scene is applied.
+	Before time.Duration `mapstructure:"before"`
+	Fade   time.Duration `mapstructure:"fade"`
+
+	// An event matches if its summary contains any of SummaryContains,
+	// ignoring case, or if VideoCall is set and it links to a video call.
+	// Without either, every timed event matches.
+	SummaryContains []string `mapstructure:"summary_contains"`
+	VideoCall       bool     `mapstructure:"video_call"`
+}
+
+// Matches reports whether the calendar applies its scene for event.
+func (c Calendar) Matches(event *Event) bool {
+	if len(c.SummaryContains) == 0 && !c.VideoCall {
+		return true
+	}
+	if c.VideoCall && event.VideoCall {
+		return true
+	}
+	summary := strings.ToLower(event.Summary)
+	for _, s := range c.SummaryContains {
+		if strings.Contains(summary, strings.ToLower(s)) {
+			return true
+		}
+	}
+	return false
+}
+
+// Filter describes which events the calendar matches.
+func (c Calendar) Filter() string {
+	var conditions []string
+	for _, s := range c.SummaryContains {
+		conditions = append(conditions, fmt.Sprintf("summary contains '%s'", s))
+	}
+	if c.VideoCall {
+		conditions = append(conditions, "video call")
+	}
+	if len(conditions) == 0 {
+		return "every event"
+	}
+	return strings.Join(conditions, " or ")
+}
+
+// Validate checks that calendar names are unique and that every calendar has
+// a path and applies an existing scene. A leading ~ in a path is expanded to
+// the home directory.
+func Validate(calendars []Calendar, scenes []scene.Scene) error {
+	seen := make(map[string]bool, len(calendars))
+	for i := range calendars {
+		c := &calendars[i]
+		if c.Name == "" {
+			return fmt.Errorf("calendar without a name")
+		}
+		if seen[c.Name] {
+			return fmt.Errorf("duplicate calendar '%s'", c.Name)
+		}
+		seen[c.Name] = true
+
+		if err := validate(c, scenes); err != nil {
+			return fmt.Errorf("calendar '%s': %w", c.Name, err)
+		}
+	}
+	return nil
+}
+
+func validate(c *Calendar, scenes []scene.Scene) error {
+	if c.Path == "" {
+		return fmt.Errorf("missing path")
+	}
+	if rest, ok := strings.CutPrefix(c.Path, "~/"); ok {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return err
+		}
+		c.Path = filepath.Join(home, rest)
+	}
+
+	if c.Scene == "" {
+		return fmt.Errorf("missing scene")
+	}
+	if scene.Find(scenes, c.Scene) == nil {
+		return fmt.Errorf("scene '%s' not found", c.Scene)
+	}
+	if c.Before < 0 {
+		return fmt.Errorf("before must not be negative")
+	}
+	if c.Fade < 0 {
+		return fmt.Errorf("fade must not be negative")
+	}
+	return nil
+}
@@ -0,0 +1,295 @@
+package calendar
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"maps"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/emersion/go-ical"
+	"github.com/teambition/rrule-go"
+)
+
+// videoCallURL matches links to the common video-call services.
+var videoCallURL = regexp.MustCompile(`(?i)https?://([a-z0-9-]+\.)*(zoom\.us|meet\.google\.com|teams\.microsoft\.com|teams\.live\.com|webex\.com|whereby\.com|meet\.jit\.si|gotomeeting\.com|chime\.aws)/`)
+
+// Event is a timed event of a calendar. All-day events are ignored.
+type Event struct {
+	UID       string
+	Summary   string
+	Start     time.Time
+	End       time.Time
+	VideoCall bool
+
+	// recurrence is nil for events that do not recur.
+	recurrence *rrule.Set
+}
+
+// Occurrence is a single time an event takes place.
+type Occurrence struct {
+	Event *Event
+	Start time.Time
+	End   time.Time
+}
+
+// Load reads the events of an iCalendar file, or of every .ics file in a
+// directory tree. Files that cannot be parsed are reported in the returned
+// error, while the events of the other files are still returned.
+func Load(path string) ([]Event, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	if !info.IsDir() {
+		return loadFile(path)
+	}
+
+	var (
+		events []Event
+		errs   []error
+	)
+	err = filepath.WalkDir(path, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() || !strings.EqualFold(filepath.Ext(p), ".ics") {
+			return nil
+		}
+		fileEvents, err := loadFile(p)
+		if err != nil {
+			errs = append(errs, err)
+		}
+		events = append(events, fileEvents...)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return events, errors.Join(errs...)
+}
+
+func loadFile(path string) ([]Event, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	events, err := Parse(file)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return events, nil
+}
+
+// Parse reads the events of an iCalendar stream. Recurring events are
+// expanded lazily by Occurrences; instances that were moved or cancelled are
+// taken into account.
+func Parse(r io.Reader) ([]Event, error) {
+	var components []*ical.Component
+	dec := ical.NewDecoder(r)
+	for {
+		cal, err := dec.Decode()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		for _, event := range cal.Events() {
+			components = append(components, event.Component)
+		}
+	}
+
+	var (
+		events []Event
+		// overridden holds, per UID, the instances of a recurring event that
+		// are replaced by a component of their own.
+		overridden = make(map[string][]time.Time)
+	)
+	for _, comp := range components {
+		uid, _ := comp.Props.Text(ical.PropUID)
+		status, _ := comp.Props.Text(ical.PropStatus)
+
+		if prop := comp.Props.Get(ical.PropRecurrenceID); prop != nil {
+			id, err := dateTime(*prop)
+			if err != nil {
+				return nil, fmt.Errorf("event '%s': invalid recurrence ID: %w", uid, err)
+			}
+			overridden[uid] = append(overridden[uid], id)
+		}
+		if strings.EqualFold(status, string(ical.EventCancelled)) {
+			continue
+		}
+
+		event, ok, err := parseEvent(comp)
+		if err != nil {
+			return nil, fmt.Errorf("event '%s': %w", uid, err)
+		}
+		if ok {
+			events = append(events, event)
+		}
+	}
+
+	for i := range events {
+		if events[i].recurrence == nil {
+			continue
+		}
+		for _, id := range overridden[events[i].UID] {
+			events[i].recurrence.ExDate(id)
+		}
+	}
+	return events, nil
+}
+
+// parseEvent returns the event described by comp, or false if it is an
+// all-day event.
+func parseEvent(comp *ical.Component) (Event, bool, error) {
+	startProp := comp.Props.Get(ical.PropDateTimeStart)
+	if startProp == nil {
+		return Event{}, false, fmt.Errorf("missing start")
+	}
+	if startProp.ValueType() == ical.ValueDate || len(startProp.Value) == len("20060102") {
+		return Event{}, false, nil
+	}
+
+	start, err := dateTime(*startProp)
+	if err != nil {
+		return Event{}, false, fmt.Errorf("invalid start: %w", err)
+	}
+	end := start
+	switch {
+	case comp.Props.Get(ical.PropDateTimeEnd) != nil:
+		if end, err = dateTime(*comp.Props.Get(ical.PropDateTimeEnd)); err != nil {
+			return Event{}, false, fmt.Errorf("invalid end: %w", err)
+		}
+	case comp.Props.Get(ical.PropDuration) != nil:
+		duration, err := comp.Props.Get(ical.PropDuration).Duration()
+		if err != nil {
+			return Event{}, false, fmt.Errorf("invalid duration: %w", err)
+		}
+		end = start.Add(duration)
+	}
+
+	event := Event{Start: start, End: end}
+	event.UID, _ = comp.Props.Text(ical.PropUID)
+	event.Summary, _ = comp.Props.Text(ical.PropSummary)
+	for _, name := range []string{ical.PropLocation, ical.PropDescription, ical.PropURL, "CONFERENCE", "X-GOOGLE-CONFERENCE"} {
+		for _, prop := range comp.Props.Values(name) {
+			if videoCallURL.MatchString(prop.Value) {
+				event.VideoCall = true
+			}
+		}
+	}
+
+	if event.recurrence, err = recurrence(comp, start); err != nil {
+		return Event{}, false, err
+	}
+	return event, true, nil
+}
+
+// recurrence returns the recurrence set of a component, or nil if it does
+// not recur.
+func recurrence(comp *ical.Component, start time.Time) (*rrule.Set, error) {
+	option, err := comp.Props.RecurrenceRule()
+	if err != nil {
+		return nil, fmt.Errorf("invalid recurrence rule: %w", err)
+	}
+	rdates := comp.Props.Values(ical.PropRecurrenceDates)
+	if option == nil && len(rdates) == 0 {
+		return nil, nil
+	}
+
+	set := &rrule.Set{}
+	if option != nil {
+		rule, err := rrule.NewRRule(*option)
+		if err != nil {
+			return nil, fmt.Errorf("invalid recurrence rule: %w", err)
+		}
+		set.RRule(rule)
+	}
+	set.DTStart(start)
+	// Without a rule, the start itself is not part of the set.
+	set.RDate(start)
+
+	for _, prop := range rdates {
+		dates, err := dateTimes(prop)
+		if err != nil {
+			return nil, fmt.Errorf("invalid recurrence date: %w", err)
+		}
+		for _, date := range dates {
+			set.RDate(date)
+		}
+	}
+	for _, prop := range comp.Props.Values(ical.PropExceptionDates) {
+		dates, err := dateTimes(prop)
+		if err != nil {
+			return nil, fmt.Errorf("invalid exception date: %w", err)
+		}
+		for _, date := range dates {
+			set.ExDate(date)
+		}
+	}
+	return set, nil
+}
+
+// dateTime parses a date-time property in its time zone. Floating times,
+// and times in a zone unknown to the time zone database, for example a
+// Windows zone name, are taken to be local.
+func dateTime(prop ical.Prop) (time.Time, error) {
+	t, err := prop.DateTime(time.Local)
+	if err != nil && prop.Params.Get(ical.ParamTimezoneID) != "" {
+		prop.Params = maps.Clone(prop.Params)
+		prop.Params.Del(ical.ParamTimezoneID)
+		return prop.DateTime(time.Local)
+	}
+	return t, err
+}
+
+// dateTimes parses a property holding a comma-separated list of date-times.
+func dateTimes(prop ical.Prop) ([]time.Time, error) {
+	var times []time.Time
+	for _, value := range strings.Split(prop.Value, ",") {
+		prop.Value = value
+		t, err := dateTime(prop)
+		if err != nil {
+			return nil, err
+		}
+		times = append(times, t)
+	}
+	return times, nil
+}
+
+// Occurrences returns the occurrences of events that overlap the period
+// [from, to), ordered by start.
+func Occurrences(events []Event, from, to time.Time) []Occurrence {
+	var occurrences []Occurrence
+	for i := range events {
+		event := &events[i]
+		duration := event.End.Sub(event.Start)
+
+		if event.recurrence == nil {
+			if event.Start.Before(to) && event.End.After(from) {
+				occurrences = append(occurrences, Occurrence{Event: event, Start: event.Start, End: event.End})
+			}
+			continue
+		}
+
+		for _, start := range event.recurrence.Between(from.Add(-duration), to, true) {
+			end := start.Add(duration)
+			if start.Before(to) && end.After(from) {
+				occurrences = append(occurrences, Occurrence{Event: event, Start: start, End: end})
+			}
+		}
+	}
+
+	sort.SliceStable(occurrences, func(i, j int) bool { return occurrences[i].Start.Before(occurrences[j].Start) })
+	return occurrences
+}
@@ -0,0 +1,80 @@
+package calendar
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/eckertalex/keylightctl/internal/keylight"
+	"github.com/eckertalex/keylightctl/internal/scene"
+)
+
+// Lights reads and changes the lights of calendar events.
+type Lights interface {
+	// Capture reads the state a light is in.
+	Capture(light keylight.Light) (keylight.LightDetail, error)
+	// Apply moves a light to the state of a target.
+	Apply(ctx context.Context, target scene.Target, fade time.Duration) error
+	// Restore moves a light back to a captured state.
+	Restore(light keylight.Light, detail keylight.LightDetail) error
+}
+
+// Start captures the state of the target lights, moves them to their target
+// states and returns a function that restores the captured states. Lights
+// whose state cannot be captured are left alone, since they could not be
+// restored after the event.
+func Start(ctx context.Context, lights Lights, targets []scene.Target, fade time.Duration) (func(context.Context) error, error) {
+	var (
+		wg       sync.WaitGroup
+		mu       sync.Mutex
+		previous = make(map[keylight.Light]keylight.LightDetail, len(targets))
+		errs     []error
+	)
+	for _, target := range targets {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			detail, err := lights.Capture(target.Light)
+			if err != nil {
+				mu.Lock()
+				errs = append(errs, fmt.Errorf("%s: left alone, reading its state failed: %w", target.Light.Name, err))
+				mu.Unlock()
+				return
+			}
+			mu.Lock()
+			previous[target.Light] = detail
+			mu.Unlock()
+
+			if err := lights.Apply(ctx, target, fade); err != nil {
+				mu.Lock()
+				errs = append(errs, fmt.Errorf("%s: %w", target.Light.Name, err))
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+
+	revert := func(ctx context.Context) error {
+		var (
+			wg   sync.WaitGroup
+			mu   sync.Mutex
+			errs []error
+		)
+		for light, detail := range previous {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				if err := lights.Restore(light, detail); err != nil {
+					mu.Lock()
+					errs = append(errs, fmt.Errorf("%s: %w", light.Name, err))
+					mu.Unlock()
+				}
+			}()
+		}
+		wg.Wait()
+		return errors.Join(errs...)
+	}
+	return revert, errors.Join(errs...)
+}
@@ -0,0 +1,240 @@
+package calendar
+
+import (
+	"context"
+	"io/fs"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/eckertalex/keylightctl/internal/clock"
+	"github.com/fsnotify/fsnotify"
+)
+
+const (
+	// maxSleep bounds how long the runner sleeps at once, so that it notices
+	// jumps of the wall clock, for example after a suspend, in time.
+	maxSleep = time.Minute
+	// lookahead is how far ahead events are searched for the next time the
+	// runner has to wake up.
+	lookahead = 48 * time.Hour
+	// reloadDelay collects the burst of file system events of a sync before
+	// a calendar is reloaded.
+	reloadDelay = time.Second
+)
+
+// Runner applies the scenes of a set of calendars around their matching
+// events.
+type Runner struct {
+	Calendars []Calendar
+	Clock     clock.Clock
+	// Start applies the scene of a calendar and returns a function that
+	// reverts the lights to their state from before.
+	Start func(ctx context.Context, c Calendar) (revert func(ctx context.Context) error, err error)
+}
+
+func NewRunner(calendars []Calendar, start func(ctx context.Context, c Calendar) (func(ctx context.Context) error, error)) *Runner {
+	return &Runner{
+		Calendars: calendars,
+		Clock:     clock.System,
+		Start:     start,
+	}
+}
+
+// calendarState is what the runner tracks per calendar.
+type calendarState struct {
+	events []Event
+	active bool
+	// summary is the summary of the event the scene was applied for.
+	summary string
+	revert  func(ctx context.Context) error
+}
+
+// Run applies and reverts the scenes of the calendars until ctx is done, and
+// then reverts the lights of calendars whose event is still running.
+// Calendars are reloaded when their files change.
+func (r *Runner) Run(ctx context.Context) {
+	if len(r.Calendars) == 0 {
+		return
+	}
+
+	states := make([]calendarState, len(r.Calendars))
+	for i := range r.Calendars {
+		states[i].events = r.load(r.Calendars[i], nil)
+	}
+	defer func() {
+		for i := range states {
+			r.deactivate(context.Background(), r.Calendars[i], &states[i])
+		}
+	}()
+
+	var (
+		changes <-chan fsnotify.Event
+		errs    <-chan error
+		dirty   = make(map[int]bool)
+		reload  <-chan time.Time
+	)
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		log.Printf("Watching calendars failed, changes will not be picked up: %v", err)
+	} else {
+		defer watcher.Close()
+		for _, c := range r.Calendars {
+			watchPath(watcher, c.Path)
+		}
+		changes, errs = watcher.Events, watcher.Errors
+	}
+
+	for {
+		// Strip the monotonic clock reading, which does not advance while the
+		// machine is suspended.
+		now := r.Clock.Now().Round(0)
+		wait := maxSleep
+		for i, c := range r.Calendars {
+			current, next := c.window(states[i].events, now)
+			if !next.IsZero() {
+				wait = min(wait, max(next.Sub(now), 0))
+			}
+
+			switch {
+			case current != nil && !states[i].active:
+				r.activate(ctx, c, &states[i], current)
+			case current == nil && states[i].active:
+				r.deactivate(ctx, c, &states[i])
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-r.Clock.After(wait):
+		case event := <-changes:
+			if event.Has(fsnotify.Create) {
+				if info, err := os.Stat(event.Name); err == nil && info.IsDir() {
+					watchPath(watcher, event.Name)
+				}
+			}
+			for i, c := range r.Calendars {
+				if c.contains(event.Name) {
+					dirty[i] = true
+				}
+			}
+			if len(dirty) > 0 && reload == nil {
+				reload = r.Clock.After(reloadDelay)
+			}
+		case err := <-errs:
+			log.Printf("Watching calendars failed: %v", err)
+		case <-reload:
+			for i := range dirty {
+				log.Printf("Calendar '%s' changed, reloading", r.Calendars[i].Name)
+				states[i].events = r.load(r.Calendars[i], states[i].events)
+			}
+			clear(dirty)
+			reload = nil
+		}
+	}
+}
+
+func (r *Runner) activate(ctx context.Context, c Calendar, state *calendarState, current *Occurrence) {
+	log.Printf("Calendar '%s': '%s' starts at %s, applying scene '%s'", c.Name, current.Event.Summary, current.Start.Format(time.Kitchen), c.Scene)
+	revert, err := r.Start(ctx, c)
+	if err != nil {
+		log.Printf("Calendar '%s' failed: %v", c.Name, err)
+	}
+	// Even if applying the scene failed, it is not retried until the next
+	// event, to not flood unreachable lights.
+	state.active = true
+	state.summary = current.Event.Summary
+	state.revert = revert
+}
+
+func (r *Runner) deactivate(ctx context.Context, c Calendar, state *calendarState) {
+	if !state.active {
+		return
+	}
+	if state.revert != nil {
+		log.Printf("Calendar '%s': '%s' ended, reverting the lights", c.Name, state.summary)
+		if err := state.revert(ctx); err != nil {
+			log.Printf("Calendar '%s' failed to revert: %v", c.Name, err)
+		}
+	}
+	*state = calendarState{events: state.events}
+}
+
+// load reads the events of a calendar. If the calendar cannot be read at all,
+// previous is kept.
+func (r *Runner) load(c Calendar, previous []Event) []Event {
+	events, err := Load(c.Path)
+	if err != nil {
+		log.Printf("Calendar '%s': %v", c.Name, err)
+		if events == nil {
+			return previous
+		}
+	}
+	return events
+}
+
+// window returns the matching occurrence whose scene applies at now, if
+// any, and the next time that changes. Overlapping and adjacent occurrences
+// form a single window.
+func (c Calendar) window(events []Event, now time.Time) (*Occurrence, time.Time) {
+	var (
+		current *Occurrence
+		end     time.Time
+	)
+	for _, o := range Occurrences(events, now, now.Add(lookahead+c.Before)) {
+		if !c.Matches(o.Event) {
+			continue
+		}
+
+		start := o.Start.Add(-c.Before)
+		switch {
+		case current == nil && start.After(now):
+			return nil, start
+		case current == nil:
+			current = &o
+			end = o.End
+		case start.After(end):
+			return current, end
+		default:
+			end = later(end, o.End)
+		}
+	}
+	return current, end
+}
+
+// contains reports whether path belongs to the calendar.
+func (c Calendar) contains(path string) bool {
+	return path == c.Path || strings.HasPrefix(path, c.Path+string(filepath.Separator))
+}
+
+// watchPath watches a calendar directory tree, or the directory of a
+// calendar file, since syncing tools replace files rather than writing to
+// them.
+func watchPath(watcher *fsnotify.Watcher, path string) {
+	info, err := os.Stat(path)
+	if err != nil || !info.IsDir() {
+		if err := watcher.Add(filepath.Dir(path)); err != nil {
+			log.Printf("Watching '%s' failed: %v", path, err)
+		}
+		return
+	}
+
+	filepath.WalkDir(path, func(p string, d fs.DirEntry, err error) error {
+		if err == nil && d.IsDir() {
+			if err := watcher.Add(p); err != nil {
+				log.Printf("Watching '%s' failed: %v", p, err)
+			}
+		}
+		return nil
+	})
+}
+
+func later(a, b time.Time) time.Time {
+	if b.After(a) {
+		return b
+	}
+	return a
+}
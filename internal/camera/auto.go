@@ -0,0 +1,220 @@
+package camera
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/eckertalex/keylightctl/internal/clock"
+	"github.com/eckertalex/keylightctl/internal/keylight"
+)
+
+const (
+	DefaultGrace    = 10 * time.Second
+	DefaultInterval = time.Second
+)
+
+// CameraConfig maps a camera to the lights it turns on.
+type CameraConfig struct {
+	Device string   `mapstructure:"device"`
+	Lights []string `mapstructure:"lights"`
+	Groups []string `mapstructure:"groups"`
+}
+
+// Config is the auto block of the config file.
+type Config struct {
+	// Grace is how long a camera must be released before its lights are
+	// restored, so that applications briefly reopening it do not make the
+	// lights flicker. Zero restores them as soon as the camera is released.
+	Grace time.Duration `mapstructure:"grace"`
+	// Interval is how often the processes are scanned.
+	Interval time.Duration  `mapstructure:"interval"`
+	Cameras  []CameraConfig `mapstructure:"cameras"`
+}
+
+// Camera is a device with the lights it turns on. An empty device matches
+// every camera.
+type Camera struct {
+	Device string
+	Lights []keylight.Light
+}
+
+// Runner turns on the lights of every camera in use, and restores their
+// previous state once the camera has been released for the grace period.
+type Runner struct {
+	Client   keylight.Client
+	Cameras  []Camera
+	Grace    time.Duration
+	Interval time.Duration
+	// ProcRoot is scanned for processes using a camera.
+	ProcRoot string
+	Clock    clock.Clock
+
+	// lastUsed is when each camera was last seen in use, by index.
+	lastUsed []time.Time
+	// previous holds the state of every light that was turned on, keyed by
+	// IP, to restore it later.
+	previous map[string]keylight.LightDetail
+}
+
+func NewRunner(client keylight.Client, cameras []Camera, grace, interval time.Duration) *Runner {
+	return &Runner{
+		Client:   client,
+		Cameras:  cameras,
+		Grace:    grace,
+		Interval: interval,
+		ProcRoot: DefaultProcRoot,
+		Clock:    clock.System,
+	}
+}
+
+// Run scans for cameras in use until ctx is done, and then restores every
+// light it turned on.
+func (r *Runner) Run(ctx context.Context) error {
+	if _, err := InUse(r.ProcRoot); err != nil {
+		return fmt.Errorf("scanning processes: %w", err)
+	}
+
+	r.lastUsed = make([]time.Time, len(r.Cameras))
+	r.previous = make(map[string]keylight.LightDetail)
+	defer r.restoreAll()
+
+	for {
+		if err := r.scan(); err != nil {
+			log.Printf("Scanning processes failed: %v", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-r.Clock.After(r.Interval):
+		}
+	}
+}
+
+// scan updates which cameras are in use and turns their lights on or
+// restores them accordingly.
+func (r *Runner) scan() error {
+	devices, err := InUse(r.ProcRoot)
+	if err != nil {
+		return err
+	}
+
+	now := r.Clock.Now()
+	wanted := make(map[string]keylight.Light)
+	for i, camera := range r.Cameras {
+		if camera.used(devices) {
+			r.lastUsed[i] = now
+		}
+		if r.lastUsed[i].IsZero() || now.Sub(r.lastUsed[i]) > r.Grace {
+			continue
+		}
+		for _, light := range camera.Lights {
+			wanted[light.IP] = light
+		}
+	}
+
+	for ip, light := range wanted {
+		if _, on := r.previous[ip]; on {
+			continue
+		}
+		if err := r.turnOn(light); err != nil {
+			log.Printf("Turning on light '%s' failed: %v", light.Name, err)
+			continue
+		}
+		log.Printf("Camera in use, turned on light '%s'", light.Name)
+	}
+
+	for _, camera := range r.Cameras {
+		for _, light := range camera.Lights {
+			if _, ok := wanted[light.IP]; ok {
+				continue
+			}
+			if _, on := r.previous[light.IP]; !on {
+				continue
+			}
+			if err := r.restore(light); err != nil {
+				log.Printf("Restoring light '%s' failed: %v", light.Name, err)
+				continue
+			}
+			log.Printf("Camera released, restored light '%s'", light.Name)
+		}
+	}
+	return nil
+}
+
+func (c Camera) used(devices map[string]bool) bool {
+	if c.Device == "" {
+		return len(devices) > 0
+	}
+	return devices[c.Device]
+}
+
+func (r *Runner) turnOn(light keylight.Light) error {
+	status, err := r.Client.GetLight(light.IP)
+	if err != nil {
+		return err
+	}
+	if len(status.Lights) == 0 {
+		return errors.New("empty status")
+	}
+
+	current := status.Lights[0]
+	on := current
+	on.On = 1
+	if _, err := r.Client.UpdateLight(light.IP, on); err != nil {
+		return err
+	}
+	r.previous[light.IP] = current
+	return nil
+}
+
+func (r *Runner) restore(light keylight.Light) error {
+	if _, err := r.Client.UpdateLight(light.IP, r.previous[light.IP]); err != nil {
+		return err
+	}
+	delete(r.previous, light.IP)
+	return nil
+}
+
+func (r *Runner) restoreAll() {
+	for _, camera := range r.Cameras {
+		for _, light := range camera.Lights {
+			if _, on := r.previous[light.IP]; !on {
+				continue
+			}
+			if err := r.restore(light); err != nil {
+				log.Printf("Restoring light '%s' failed: %v", light.Name, err)
+			}
+		}
+	}
+}
+
+// Validate checks the grace period, interval and camera mappings of the
+// config, and fills in the default interval. The grace period is left as it
+// is, since zero is valid.
+func Validate(c *Config) error {
+	if c.Grace < 0 {
+		return fmt.Errorf("grace must not be negative")
+	}
+	if c.Interval == 0 {
+		c.Interval = DefaultInterval
+	}
+	if c.Interval < 0 {
+		return fmt.Errorf("interval must be positive")
+	}
+
+	seen := make(map[string]bool, len(c.Cameras))
+	for _, camera := range c.Cameras {
+		if camera.Device == "" {
+			return fmt.Errorf("camera without a device")
+		}
+		if seen[camera.Device] {
+			return fmt.Errorf("duplicate camera '%s'", camera.Device)
+		}
+		seen[camera.Device] = true
+	}
+	return nil
+}
@@ -0,0 +1,137 @@
+package camera
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/eckertalex/keylightctl/internal/keylight"
+	"github.com/eckertalex/keylightctl/internal/keylighttest"
+)
+
+var (
+	left  = keylight.Light{Name: "Left", IP: "10.0.0.1"}
+	right = keylight.Light{Name: "Right", IP: "10.0.0.2"}
+	key   = keylight.Light{Name: "Key", IP: "10.0.0.3"}
+
+	off = keylight.LightDetail{On: 0, Brightness: 40, Temperature: 250}
+)
+
+func newTestRunner(t *testing.T, grace time.Duration) (*Runner, *keylighttest.Lights, string) {
+	t.Helper()
+
+	lights := keylighttest.NewLights(map[string]keylight.LightDetail{left.IP: off, right.IP: off, key.IP: off})
+	root := t.TempDir()
+	r := NewRunner(lights, []Camera{
+		{Device: "/dev/video0", Lights: []keylight.Light{left, right}},
+		{Device: "/dev/video1", Lights: []keylight.Light{right, key}},
+	}, grace, time.Second)
+	r.ProcRoot = root
+	r.Clock = keylighttest.NewClock(keylighttest.Date(10, 9, 0))
+	r.lastUsed = make([]time.Time, len(r.Cameras))
+	r.previous = make(map[string]keylight.LightDetail)
+	return r, lights, root
+}
+
+// scan scans once, after the clock moved on by d.
+func scan(t *testing.T, r *Runner, d time.Duration) {
+	t.Helper()
+	r.Clock.(*keylighttest.Clock).Advance(d)
+	if err := r.scan(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func checkOn(t *testing.T, lights *keylighttest.Lights, want map[keylight.Light]bool) {
+	t.Helper()
+	for light, on := range want {
+		if got := lights.Get(light.IP).On == 1; got != on {
+			t.Errorf("light '%s' on = %v, want %v", light.Name, got, on)
+		}
+	}
+}
+
+func TestRunner(t *testing.T) {
+	r, lights, root := newTestRunner(t, 10*time.Second)
+
+	scan(t, r, time.Second)
+	checkOn(t, lights, map[keylight.Light]bool{left: false, right: false, key: false})
+
+	addProcess(t, root, "100", "/dev/video0")
+	scan(t, r, time.Second)
+	checkOn(t, lights, map[keylight.Light]bool{left: true, right: true, key: false})
+
+	addProcess(t, root, "200", "/dev/video1")
+	scan(t, r, time.Second)
+	checkOn(t, lights, map[keylight.Light]bool{left: true, right: true, key: true})
+	if updates := lights.Updates(right.IP); len(updates) != 1 {
+		t.Errorf("shared light was updated %d times, want once", len(updates))
+	}
+
+	// The lights of a released camera stay on for the grace period.
+	if err := os.RemoveAll(filepath.Join(root, "100")); err != nil {
+		t.Fatal(err)
+	}
+	scan(t, r, 5*time.Second)
+	checkOn(t, lights, map[keylight.Light]bool{left: true, right: true, key: true})
+
+	// A light shared with a camera still in use stays on after it.
+	scan(t, r, 6*time.Second)
+	checkOn(t, lights, map[keylight.Light]bool{left: false, right: true, key: true})
+	if got := lights.Get(left.IP); got != off {
+		t.Errorf("restored %+v, want %+v", got, off)
+	}
+
+	r.restoreAll()
+	for _, light := range []keylight.Light{left, right, key} {
+		if got := lights.Get(light.IP); got != off {
+			t.Errorf("light '%s' is %+v on exit, want %+v", light.Name, got, off)
+		}
+	}
+}
+
+func TestRunnerWithoutGrace(t *testing.T) {
+	r, lights, root := newTestRunner(t, 0)
+
+	addProcess(t, root, "100", "/dev/video1")
+	scan(t, r, time.Second)
+	checkOn(t, lights, map[keylight.Light]bool{right: true, key: true})
+
+	if err := os.RemoveAll(filepath.Join(root, "100")); err != nil {
+		t.Fatal(err)
+	}
+	scan(t, r, time.Second)
+	checkOn(t, lights, map[keylight.Light]bool{right: false, key: false})
+}
+
+func TestRunRestoresOnExit(t *testing.T) {
+	r, lights, root := newTestRunner(t, time.Hour)
+	addProcess(t, root, "100", "/dev/video0")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := r.Run(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(lights.Updates(left.IP)) == 0 {
+		t.Error("the lights were not turned on")
+	}
+	for _, light := range []keylight.Light{left, right, key} {
+		if got := lights.Get(light.IP); got != off {
+			t.Errorf("light '%s' is %+v after Run, want %+v", light.Name, got, off)
+		}
+	}
+}
+
+func TestValidateKeepsZeroGrace(t *testing.T) {
+	c := Config{}
+	if err := Validate(&c); err != nil {
+		t.Fatal(err)
+	}
+	if c.Grace != 0 || c.Interval != DefaultInterval {
+		t.Errorf("got grace %s, interval %s, want 0s, %s", c.Grace, c.Interval, DefaultInterval)
+	}
+}
@@ -0,0 +1,48 @@
+// Package camera turns lights on while a webcam is in use.
+package camera
+
+import (
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// DefaultProcRoot is where the proc file system is mounted on Linux.
+const DefaultProcRoot = "/proc"
+
+// devicePrefix matches the video4linux devices cameras show up as.
+const devicePrefix = "/dev/video"
+
+// InUse returns the video devices opened by any process, found by scanning
+// the file descriptors under procRoot. Processes whose file descriptors
+// cannot be read, usually those of other users, are skipped.
+func InUse(procRoot string) (map[string]bool, error) {
+	entries, err := os.ReadDir(procRoot)
+	if err != nil {
+		return nil, err
+	}
+
+	devices := make(map[string]bool)
+	for _, entry := range entries {
+		if _, err := strconv.Atoi(entry.Name()); err != nil {
+			continue
+		}
+
+		fdDir := filepath.Join(procRoot, entry.Name(), "fd")
+		fds, err := os.ReadDir(fdDir)
+		if err != nil {
+			continue
+		}
+		for _, fd := range fds {
+			target, err := os.Readlink(filepath.Join(fdDir, fd.Name()))
+			if err != nil {
+				continue
+			}
+			if strings.HasPrefix(target, devicePrefix) {
+				devices[target] = true
+			}
+		}
+	}
+	return devices, nil
+}
@@ -0,0 +1,83 @@
+package camera
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// addProcess adds a process to a fake proc tree, with a file descriptor
+// pointing at each target.
+func addProcess(t *testing.T, root, pid string, targets ...string) string {
+	t.Helper()
+
+	fdDir := filepath.Join(root, pid, "fd")
+	if err := os.MkdirAll(fdDir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	for i, target := range targets {
+		if err := os.Symlink(target, filepath.Join(fdDir, string(rune('0'+i)))); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return fdDir
+}
+
+func TestInUse(t *testing.T) {
+	t.Run("active", func(t *testing.T) {
+		root := t.TempDir()
+		addProcess(t, root, "100", "/dev/null", "/dev/video0")
+		addProcess(t, root, "200", "socket:[1234]", "/dev/video2")
+
+		devices, err := InUse(root)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(devices) != 2 || !devices["/dev/video0"] || !devices["/dev/video2"] {
+			t.Errorf("got %v, want /dev/video0 and /dev/video2", devices)
+		}
+	})
+
+	t.Run("inactive", func(t *testing.T) {
+		root := t.TempDir()
+		addProcess(t, root, "100", "/dev/null", "/home/me/notes.txt")
+		// Entries that are not processes are ignored.
+		addProcess(t, root, "self", "/dev/video0")
+
+		devices, err := InUse(root)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(devices) != 0 {
+			t.Errorf("got %v, want no devices", devices)
+		}
+	})
+
+	t.Run("permission denied", func(t *testing.T) {
+		if os.Geteuid() == 0 {
+			t.Skip("permissions are not enforced for root")
+		}
+
+		root := t.TempDir()
+		denied := addProcess(t, root, "100", "/dev/video0")
+		addProcess(t, root, "200", "/dev/video1")
+		if err := os.Chmod(denied, 0); err != nil {
+			t.Fatal(err)
+		}
+		t.Cleanup(func() { os.Chmod(denied, 0o755) })
+
+		devices, err := InUse(root)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(devices) != 1 || !devices["/dev/video1"] {
+			t.Errorf("got %v, want only /dev/video1", devices)
+		}
+	})
+
+	t.Run("missing root", func(t *testing.T) {
+		if _, err := InUse(filepath.Join(t.TempDir(), "missing")); err == nil {
+			t.Error("expected an error")
+		}
+	})
+}
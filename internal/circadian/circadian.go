@@ -0,0 +1,119 @@
+// Package circadian sets the color temperature of the lights along the
+// course of the sun.
+package circadian
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/eckertalex/keylightctl/internal/keylight"
+)
+
+const (
+	DefaultDayTemperature   = 6500
+	DefaultNightTemperature = 3000
+	// DefaultNightElevation is civil dusk, when the sun is 6 degrees below
+	// the horizon.
+	DefaultNightElevation = -6.0
+	DefaultInterval       = time.Minute
+	DefaultPause          = 30 * time.Minute
+)
+
+// Curve maps the elevation of the sun to a color temperature. Between the
+// night and the day elevation the temperature changes linearly with the
+// elevation.
+type Curve struct {
+	// DayTemperature and NightTemperature are in Kelvin.
+	DayTemperature   int `mapstructure:"day_temperature"`
+	NightTemperature int `mapstructure:"night_temperature"`
+	// NightElevation is the elevation, in degrees, at and below which the
+	// night temperature applies.
+	NightElevation *float64 `mapstructure:"night_elevation"`
+	// DayElevation is the elevation, in degrees, at and above which the day
+	// temperature applies. If it is unset, the day temperature is only
+	// reached at solar noon.
+	DayElevation *float64 `mapstructure:"day_elevation"`
+}
+
+// Config is the circadian block of the config file.
+type Config struct {
+	Latitude  float64 `mapstructure:"latitude"`
+	Longitude float64 `mapstructure:"longitude"`
+	// Lights and Groups select the participating lights. Every light
+	// participates if both are empty.
+	Lights []string `mapstructure:"lights"`
+	Groups []string `mapstructure:"groups"`
+	Curve  `mapstructure:",squash"`
+	// Interval is how often the temperature is updated.
+	Interval time.Duration `mapstructure:"interval"`
+	// Pause is how long a light is left alone after its temperature was
+	// changed by someone else.
+	Pause time.Duration `mapstructure:"pause"`
+}
+
+// Temperature returns the color temperature at t, in Kelvin, as seen from
+// the given latitude and longitude.
+func (c Curve) Temperature(t time.Time, lat, lon float64) int {
+	night := DefaultNightElevation
+	if c.NightElevation != nil {
+		night = *c.NightElevation
+	}
+	var day float64
+	if c.DayElevation != nil {
+		day = *c.DayElevation
+	} else {
+		day = SunDay(t, lat, lon).NoonElevation
+	}
+
+	elevation := Elevation(t, lat, lon)
+	switch {
+	case elevation <= night:
+		return c.NightTemperature
+	case elevation >= day:
+		return c.DayTemperature
+	}
+	progress := (elevation - night) / (day - night)
+	return c.NightTemperature + int(progress*float64(c.DayTemperature-c.NightTemperature))
+}
+
+// Validate checks the coordinates, temperatures and elevations of the
+// config, and fills in defaults for the temperatures, interval and pause.
+func Validate(c *Config) error {
+	if c.Latitude < -90 || c.Latitude > 90 {
+		return fmt.Errorf("latitude must be between -90 and 90")
+	}
+	if c.Longitude < -180 || c.Longitude > 180 {
+		return fmt.Errorf("longitude must be between -180 and 180")
+	}
+
+	if c.DayTemperature == 0 {
+		c.DayTemperature = DefaultDayTemperature
+	}
+	if c.NightTemperature == 0 {
+		c.NightTemperature = DefaultNightTemperature
+	}
+	if err := keylight.ValidateTemperature(c.DayTemperature); err != nil {
+		return fmt.Errorf("day %w", err)
+	}
+	if err := keylight.ValidateTemperature(c.NightTemperature); err != nil {
+		return fmt.Errorf("night %w", err)
+	}
+
+	if c.NightElevation != nil && c.DayElevation != nil && *c.NightElevation >= *c.DayElevation {
+		return fmt.Errorf("night elevation must be below day elevation")
+	}
+
+	if c.Interval == 0 {
+		c.Interval = DefaultInterval
+	}
+	if c.Interval < 0 {
+		return fmt.Errorf("interval must be positive")
+	}
+	if c.Pause == 0 {
+		c.Pause = DefaultPause
+	}
+	if c.Pause < 0 {
+		return fmt.Errorf("pause must not be negative")
+	}
+	return nil
+}
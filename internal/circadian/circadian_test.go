@@ -0,0 +1,55 @@
+package circadian
+
+import (
+	"testing"
+	"time"
+)
+
+func float(v float64) *float64 {
+	return &v
+}
+
+func TestCurveTemperature(t *testing.T) {
+	summer := SunDay(time.Date(2026, time.June, 21, 12, 0, 0, 0, cest), berlin.lat, berlin.lon)
+	winter := SunDay(time.Date(2025, time.December, 21, 12, 0, 0, 0, cet), berlin.lat, berlin.lon)
+
+	tests := []struct {
+		name  string
+		curve Curve
+		t     time.Time
+		// The temperature must be within min and max.
+		min, max int
+	}{
+		{"night", Curve{}, winter.Noon.Add(-6 * time.Hour), 3000, 3000},
+		{"at the night elevation", Curve{NightElevation: float(-0.833)}, winter.Sunrise, 3000, 3050},
+		{"above the day elevation", Curve{DayElevation: float(30)}, summer.Noon.Add(-3 * time.Hour), 6500, 6500},
+		{"noon without a day elevation", Curve{}, summer.Noon, 6490, 6500},
+		// The sun is at -0.833°, a third of the way from -6° to 10°.
+		{"between", Curve{NightElevation: float(-6), DayElevation: float(10)}, summer.Sunrise, 4100, 4160},
+		// The sun reaches 14°, 56% of the way from -6° to 30°.
+		{"low winter noon", Curve{DayElevation: float(30)}, winter.Noon, 4920, 4980},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			curve := tt.curve
+			curve.DayTemperature, curve.NightTemperature = 6500, 3000
+			if got := curve.Temperature(tt.t, berlin.lat, berlin.lon); got < tt.min || got > tt.max {
+				t.Errorf("Temperature at %s = %dK, want %d-%dK", tt.t, got, tt.min, tt.max)
+			}
+		})
+	}
+}
+
+func TestCurveTemperatureFollowsTheSun(t *testing.T) {
+	curve := Curve{DayTemperature: 6500, NightTemperature: 3000}
+	day := SunDay(time.Date(2026, time.March, 20, 12, 0, 0, 0, cet), berlin.lat, berlin.lon)
+
+	prev := curve.Temperature(day.Noon.Add(-12*time.Hour), berlin.lat, berlin.lon)
+	for t0 := day.Noon.Add(-12 * time.Hour); t0.Before(day.Noon); t0 = t0.Add(10 * time.Minute) {
+		got := curve.Temperature(t0, berlin.lat, berlin.lon)
+		if got < prev {
+			t.Fatalf("temperature fell from %dK to %dK in the morning, at %s", prev, got, t0)
+		}
+		prev = got
+	}
+}
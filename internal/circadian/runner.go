@@ -0,0 +1,127 @@
+package circadian
+
+import (
+	"context"
+	"errors"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/eckertalex/keylightctl/internal/clock"
+	"github.com/eckertalex/keylightctl/internal/keylight"
+)
+
+// Runner keeps the temperature of a set of lights on the curve, leaving
+// their power and brightness alone.
+type Runner struct {
+	Client keylight.Client
+	Lights []keylight.Light
+	Config Config
+	Clock  clock.Clock
+}
+
+func NewRunner(client keylight.Client, lights []keylight.Light, config Config) *Runner {
+	return &Runner{
+		Client: client,
+		Lights: lights,
+		Config: config,
+		Clock:  clock.System,
+	}
+}
+
+// Run updates the temperature of every light until ctx is done.
+func (r *Runner) Run(ctx context.Context) {
+	var wg sync.WaitGroup
+	for _, light := range r.Lights {
+		wg.Add(1)
+		go func(light keylight.Light) {
+			defer wg.Done()
+			r.runLight(ctx, light)
+		}(light)
+	}
+	wg.Wait()
+}
+
+// Target returns the temperature the lights are moved to at t, in Kelvin.
+func (r *Runner) Target(t time.Time) int {
+	return r.Config.Temperature(t, r.Config.Latitude, r.Config.Longitude)
+}
+
+// runLight updates the temperature of a single light. A light whose
+// temperature differs from the one last set was adjusted by someone else,
+// and is paused until it has been left alone for the configured time.
+func (r *Runner) runLight(ctx context.Context, light keylight.Light) {
+	var (
+		// expected is the temperature last set or observed, in mired.
+		expected    int
+		pausedUntil time.Time
+		failing     bool
+	)
+
+	for {
+		now := r.Clock.Now()
+		current, err := r.get(light)
+		switch {
+		case err != nil:
+			if !failing {
+				log.Printf("Light '%s' failed: %v", light.Name, err)
+			}
+			failing = true
+		case expected != 0 && current.Temperature != expected:
+			if !now.Before(pausedUntil) {
+				log.Printf("Light '%s' was adjusted manually, pausing for %s", light.Name, r.Config.Pause)
+			}
+			expected = current.Temperature
+			pausedUntil = now.Add(r.Config.Pause)
+			failing = false
+		case now.Before(pausedUntil):
+			failing = false
+		default:
+			if failing {
+				log.Printf("Light '%s' is reachable again", light.Name)
+			}
+			failing = false
+
+			target := keylight.KelvinToMiredExact(r.Target(now))
+			if current.Temperature == target {
+				expected = target
+				break
+			}
+			if expected, err = r.set(light, current, target); err != nil {
+				log.Printf("Light '%s' failed: %v", light.Name, err)
+				failing = true
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-r.Clock.After(r.Config.Interval):
+		}
+	}
+}
+
+func (r *Runner) get(light keylight.Light) (keylight.LightDetail, error) {
+	status, err := r.Client.GetLight(light.IP)
+	if err != nil {
+		return keylight.LightDetail{}, err
+	}
+	if len(status.Lights) == 0 {
+		return keylight.LightDetail{}, errors.New("empty status")
+	}
+	return status.Lights[0], nil
+}
+
+// set changes the temperature of a light and returns the temperature it
+// reports back.
+func (r *Runner) set(light keylight.Light, current keylight.LightDetail, temperature int) (int, error) {
+	current.Temperature = temperature
+	status, err := r.Client.UpdateLight(light.IP, current)
+	if err != nil {
+		return 0, err
+	}
+	if len(status.Lights) > 0 && status.Lights[0].Temperature != 0 {
+		return status.Lights[0].Temperature, nil
+	}
+	return temperature, nil
+}
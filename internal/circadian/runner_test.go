@@ -0,0 +1,78 @@
+package circadian
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/eckertalex/keylightctl/internal/keylight"
+	"github.com/eckertalex/keylightctl/internal/keylighttest"
+)
+
+const ip = "10.0.0.1"
+
+// steppedClock is a fake clock that calls step before every wait, with the
+// number of waits so far. Once step returns false the wait never ends.
+type steppedClock struct {
+	*keylighttest.Clock
+	waits int
+	step  func(waits int) bool
+}
+
+func (c *steppedClock) After(d time.Duration) <-chan time.Time {
+	c.waits++
+	if !c.step(c.waits) {
+		return nil
+	}
+	return c.Clock.After(d)
+}
+
+func TestRunnerPausesAfterManualChange(t *testing.T) {
+	// At midnight in January the night temperature applies throughout.
+	start := keylighttest.Date(10, 0, 0)
+	night := keylight.KelvinToMiredExact(3000)
+	lights := keylighttest.NewLights(map[string]keylight.LightDetail{ip: {On: 1, Brightness: 50, Temperature: 200}})
+	clock := keylighttest.NewClock(start)
+	var times []time.Duration
+	lights.AfterUpdate = func(string, keylight.LightDetail) { times = append(times, clock.Now().Sub(start)) }
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	r := NewRunner(lights, []keylight.Light{{Name: "Left", IP: ip}}, Config{
+		Latitude:  berlin.lat,
+		Longitude: berlin.lon,
+		Curve:     Curve{DayTemperature: 6500, NightTemperature: 3000},
+		Interval:  time.Minute,
+		Pause:     30 * time.Minute,
+	})
+	r.Clock = &steppedClock{Clock: clock, step: func(waits int) bool {
+		switch waits {
+		case 2:
+			// Changed manually before the poll at 2 minutes.
+			lights.Set(ip, keylight.LightDetail{On: 1, Brightness: 50, Temperature: 250})
+		case 12:
+			// Changed again during the pause, which starts over.
+			lights.Set(ip, keylight.LightDetail{On: 1, Brightness: 80, Temperature: 260})
+		case 60:
+			cancel()
+			return false
+		}
+		return true
+	}}
+
+	r.runLight(ctx, r.Lights[0])
+
+	want := []time.Duration{0, 42 * time.Minute}
+	if len(times) != len(want) {
+		t.Fatalf("updated at %v, want %v", times, want)
+	}
+	for i := range want {
+		if times[i] != want[i] {
+			t.Errorf("updated at %v, want %v", times, want)
+			break
+		}
+	}
+	if got, want := lights.Get(ip), (keylight.LightDetail{On: 1, Brightness: 80, Temperature: night}); got != want {
+		t.Errorf("light is %+v, want %+v", got, want)
+	}
+}
@@ -0,0 +1,121 @@
+package circadian
+
+import (
+	"math"
+	"time"
+)
+
+// The solar calculations follow NOAA's solar calculator, which is accurate to
+// about a minute for dates between 1800 and 2100.
+
+// sunriseZenith accounts for atmospheric refraction and the size of the sun's
+// disc at sunrise and sunset.
+const sunriseZenith = 90.833
+
+type solarPosition struct {
+	// declination of the sun, in degrees.
+	declination float64
+	// equationOfTime is the difference between apparent and mean solar time,
+	// in minutes.
+	equationOfTime float64
+}
+
+func position(t time.Time) solarPosition {
+	julianDay := float64(t.Unix())/86400 + 2440587.5
+	jc := (julianDay - 2451545) / 36525
+
+	meanLong := math.Mod(280.46646+jc*(36000.76983+jc*0.0003032), 360)
+	meanAnomaly := 357.52911 + jc*(35999.05029-0.0001537*jc)
+	eccentricity := 0.016708634 - jc*(0.000042037+0.0000001267*jc)
+
+	center := sin(meanAnomaly)*(1.914602-jc*(0.004817+0.000014*jc)) +
+		sin(2*meanAnomaly)*(0.019993-0.000101*jc) +
+		sin(3*meanAnomaly)*0.000289
+	apparentLong := meanLong + center - 0.00569 - 0.00478*sin(125.04-1934.136*jc)
+
+	meanObliquity := 23 + (26+(21.448-jc*(46.815+jc*(0.00059-jc*0.001813)))/60)/60
+	obliquity := meanObliquity + 0.00256*cos(125.04-1934.136*jc)
+
+	declination := degrees(math.Asin(sin(obliquity) * sin(apparentLong)))
+
+	y := math.Pow(math.Tan(radians(obliquity/2)), 2)
+	equationOfTime := 4 * degrees(y*sin(2*meanLong)-
+		2*eccentricity*sin(meanAnomaly)+
+		4*eccentricity*y*sin(meanAnomaly)*cos(2*meanLong)-
+		0.5*y*y*sin(4*meanLong)-
+		1.25*eccentricity*eccentricity*sin(2*meanAnomaly))
+
+	return solarPosition{declination: declination, equationOfTime: equationOfTime}
+}
+
+// Elevation returns the angle of the sun above the horizon at t, in degrees,
+// as seen from the given latitude and longitude.
+func Elevation(t time.Time, lat, lon float64) float64 {
+	pos := position(t)
+
+	utc := t.UTC()
+	minutes := float64(utc.Hour()*60+utc.Minute()) + float64(utc.Second())/60
+	trueSolarTime := math.Mod(minutes+pos.equationOfTime+4*lon, 1440)
+	if trueSolarTime < 0 {
+		trueSolarTime += 1440
+	}
+	hourAngle := trueSolarTime/4 - 180
+
+	cosZenith := sin(lat)*sin(pos.declination) + cos(lat)*cos(pos.declination)*cos(hourAngle)
+	return 90 - degrees(math.Acos(clamp(cosZenith, -1, 1)))
+}
+
+// Day describes the course of the sun on a day.
+type Day struct {
+	Noon time.Time
+	// NoonElevation is the highest elevation the sun reaches, in degrees.
+	NoonElevation float64
+	// Sunrise and Sunset are zero during polar day and polar night.
+	Sunrise time.Time
+	Sunset  time.Time
+}
+
+// SunDay returns the course of the sun on the day of t, in t's location, as
+// seen from the given latitude and longitude.
+func SunDay(t time.Time, lat, lon float64) Day {
+	year, month, day := t.Date()
+	midnight := time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+
+	// Evaluate the sun's position around the local solar noon.
+	approxNoon := midnight.Add(time.Duration((720 - 4*lon) * float64(time.Minute)))
+	pos := position(approxNoon)
+
+	noon := midnight.Add(time.Duration((720 - 4*lon - pos.equationOfTime) * float64(time.Minute)))
+	out := Day{
+		Noon:          noon.In(t.Location()),
+		NoonElevation: 90 - math.Abs(lat-pos.declination),
+	}
+
+	cosHourAngle := cos(sunriseZenith)/(cos(lat)*cos(pos.declination)) - math.Tan(radians(lat))*math.Tan(radians(pos.declination))
+	if cosHourAngle >= -1 && cosHourAngle <= 1 {
+		offset := time.Duration(4 * degrees(math.Acos(cosHourAngle)) * float64(time.Minute))
+		out.Sunrise = noon.Add(-offset).In(t.Location())
+		out.Sunset = noon.Add(offset).In(t.Location())
+	}
+	return out
+}
+
+func radians(deg float64) float64 {
+	return deg * math.Pi / 180
+}
+
+func degrees(rad float64) float64 {
+	return rad * 180 / math.Pi
+}
+
+func sin(deg float64) float64 {
+	return math.Sin(radians(deg))
+}
+
+func cos(deg float64) float64 {
+	return math.Cos(radians(deg))
+}
+
+func clamp(v, lo, hi float64) float64 {
+	return math.Min(math.Max(v, lo), hi)
+}
@@ -0,0 +1,110 @@
+package circadian
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+var (
+	cet  = time.FixedZone("CET", 1*60*60)
+	cest = time.FixedZone("CEST", 2*60*60)
+	aest = time.FixedZone("AEST", 10*60*60)
+)
+
+type place struct {
+	lat, lon float64
+}
+
+var (
+	berlin = place{52.52, 13.405}
+	sydney = place{-33.87, 151.21}
+	tromso = place{69.65, 18.96}
+)
+
+func clockTime(day time.Time, hour, minute int) time.Time {
+	return time.Date(day.Year(), day.Month(), day.Day(), hour, minute, 0, 0, day.Location())
+}
+
+func TestSunDay(t *testing.T) {
+	summer := time.Date(2026, time.June, 21, 12, 0, 0, 0, cest)
+	winter := time.Date(2025, time.December, 21, 12, 0, 0, 0, cet)
+	sydneyWinter := time.Date(2026, time.June, 21, 12, 0, 0, 0, aest)
+
+	// Published times, rounded to the minute.
+	tests := []struct {
+		name    string
+		day     time.Time
+		place   place
+		sunrise time.Time
+		noon    time.Time
+		sunset  time.Time
+	}{
+		{"Berlin, summer solstice", summer, berlin, clockTime(summer, 4, 43), clockTime(summer, 13, 8), clockTime(summer, 21, 33)},
+		{"Berlin, winter solstice", winter, berlin, clockTime(winter, 8, 15), clockTime(winter, 12, 5), clockTime(winter, 15, 54)},
+		{"Sydney, winter solstice", sydneyWinter, sydney, clockTime(sydneyWinter, 7, 0), clockTime(sydneyWinter, 11, 57), clockTime(sydneyWinter, 16, 54)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			day := SunDay(tt.day, tt.place.lat, tt.place.lon)
+			for _, c := range []struct {
+				what      string
+				got, want time.Time
+			}{{"sunrise", day.Sunrise, tt.sunrise}, {"noon", day.Noon, tt.noon}, {"sunset", day.Sunset, tt.sunset}} {
+				if diff := c.got.Sub(c.want); diff.Abs() > 2*time.Minute {
+					t.Errorf("%s at %s, want %s", c.what, c.got.Format(time.TimeOnly), c.want.Format(time.TimeOnly))
+				}
+			}
+		})
+	}
+}
+
+func TestSunDayPolar(t *testing.T) {
+	tests := []struct {
+		name  string
+		day   time.Time
+		above bool
+	}{
+		{"polar day", time.Date(2026, time.June, 21, 12, 0, 0, 0, cest), true},
+		{"polar night", time.Date(2025, time.December, 21, 12, 0, 0, 0, cet), false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			day := SunDay(tt.day, tromso.lat, tromso.lon)
+			if !day.Sunrise.IsZero() || !day.Sunset.IsZero() {
+				t.Errorf("got sunrise %s and sunset %s, want none", day.Sunrise, day.Sunset)
+			}
+			if above := day.NoonElevation > 0; above != tt.above {
+				t.Errorf("noon elevation %.1f°", day.NoonElevation)
+			}
+		})
+	}
+}
+
+func TestElevation(t *testing.T) {
+	summer := SunDay(time.Date(2026, time.June, 21, 12, 0, 0, 0, cest), berlin.lat, berlin.lon)
+	winter := SunDay(time.Date(2025, time.December, 21, 12, 0, 0, 0, cet), berlin.lat, berlin.lon)
+
+	tests := []struct {
+		name string
+		t    time.Time
+		want float64
+	}{
+		// 90° less the latitude, plus the declination of the sun.
+		{"summer noon", summer.Noon, 90 - 52.52 + 23.44},
+		{"winter noon", winter.Noon, 90 - 52.52 - 23.44},
+		// The sun's upper edge appears on the horizon, refracted.
+		{"sunrise", summer.Sunrise, 90 - sunriseZenith},
+		{"sunset", winter.Sunset, 90 - sunriseZenith},
+		// At solar midnight the sun is as far below the horizon as it is
+		// above it at noon on the opposite solstice.
+		{"summer midnight", summer.Noon.Add(12 * time.Hour), 52.52 + 23.44 - 90},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Elevation(tt.t, berlin.lat, berlin.lon); math.Abs(got-tt.want) > 0.2 {
+				t.Errorf("Elevation at %s = %.2f°, want %.2f°", tt.t, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,154 @@
+// Package effect plays short notification effects on the lights and
+// restores their state afterwards.
+package effect
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/eckertalex/keylightctl/internal/keylight"
+)
+
+// step is the interval between updates of a light during an effect.
+const step = 100 * time.Millisecond
+
+// minBrightness is the low point of pulse and breathe.
+const minBrightness = 1
+
+type Effect string
+
+const (
+	// Blink switches the lights on and off.
+	Blink Effect = "blink"
+	// Pulse ramps the brightness up and down linearly.
+	Pulse Effect = "pulse"
+	// Breathe ramps the brightness up and down smoothly.
+	Breathe Effect = "breathe"
+)
+
+var Effects = []Effect{Blink, Pulse, Breathe}
+
+// DefaultPeriods is the default duration of one cycle of each effect.
+var DefaultPeriods = map[Effect]time.Duration{
+	Blink:   600 * time.Millisecond,
+	Pulse:   time.Second,
+	Breathe: 3 * time.Second,
+}
+
+func ParseEffect(s string) (Effect, error) {
+	effect := Effect(s)
+	if !slices.Contains(Effects, effect) {
+		return "", fmt.Errorf("unknown effect '%s', expected one of %v", s, Effects)
+	}
+	return effect, nil
+}
+
+type Options struct {
+	Effect Effect
+	// Count is the number of cycles.
+	Count  int
+	Period time.Duration
+	// Brightness is the peak brightness of the effect.
+	Brightness int
+}
+
+// frame returns the state of a light at the given progress through a cycle,
+// between 0 and 1. The temperature is left to the caller.
+func (o Options) frame(progress float64) keylight.LightDetail {
+	switch o.Effect {
+	case Blink:
+		if progress < 0.5 {
+			return keylight.LightDetail{On: 1, Brightness: o.Brightness}
+		}
+		return keylight.LightDetail{On: 0, Brightness: o.Brightness}
+	case Pulse:
+		level := 1 - math.Abs(2*progress-1)
+		return keylight.LightDetail{On: 1, Brightness: scale(o.Brightness, level)}
+	default:
+		level := (1 - math.Cos(2*math.Pi*progress)) / 2
+		return keylight.LightDetail{On: 1, Brightness: scale(o.Brightness, level)}
+	}
+}
+
+func scale(peak int, level float64) int {
+	return minBrightness + int(math.Round(float64(peak-minBrightness)*level))
+}
+
+// Play runs the effect on every light in parallel. Each light is restored to
+// its exact previous state afterwards, also when ctx is cancelled. Lights
+// whose state cannot be read are skipped.
+func Play(ctx context.Context, client keylight.Client, lights []keylight.Light, opts Options) error {
+	var (
+		wg   sync.WaitGroup
+		mu   sync.Mutex
+		errs []error
+	)
+	for _, light := range lights {
+		wg.Add(1)
+		go func(light keylight.Light) {
+			defer wg.Done()
+			if err := play(ctx, client, light.IP, opts); err != nil {
+				mu.Lock()
+				errs = append(errs, fmt.Errorf("%s: %w", light.Name, err))
+				mu.Unlock()
+			}
+		}(light)
+	}
+	wg.Wait()
+
+	return errors.Join(errs...)
+}
+
+func play(ctx context.Context, client keylight.Client, ip string, opts Options) error {
+	status, err := client.GetLight(ip)
+	if err != nil {
+		return err
+	}
+	if len(status.Lights) == 0 {
+		return errors.New("empty status")
+	}
+	previous := status.Lights[0]
+
+	err = run(ctx, client, ip, opts, previous.Temperature)
+	if _, restoreErr := client.UpdateLight(ip, previous); restoreErr != nil {
+		return errors.Join(err, fmt.Errorf("restoring: %w", restoreErr))
+	}
+	return err
+}
+
+// run plays the frames of the effect, only updating the light when its state
+// changes.
+func run(ctx context.Context, client keylight.Client, ip string, opts Options, temperature int) error {
+	ticker := time.NewTicker(step)
+	defer ticker.Stop()
+
+	total := time.Duration(opts.Count) * opts.Period
+	start := time.Now()
+	var last keylight.LightDetail
+	for {
+		elapsed := time.Since(start)
+		if elapsed >= total {
+			return nil
+		}
+
+		detail := opts.frame(float64(elapsed%opts.Period) / float64(opts.Period))
+		detail.Temperature = temperature
+		if detail != last {
+			if _, err := client.UpdateLight(ip, detail); err != nil {
+				return err
+			}
+			last = detail
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
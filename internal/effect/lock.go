@@ -0,0 +1,34 @@
+package effect
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// DefaultLockPath is the lock file that queues the notifications of all
+// processes of the current user.
+func DefaultLockPath() string {
+	if dir := os.Getenv("XDG_RUNTIME_DIR"); dir != "" {
+		return filepath.Join(dir, "keylightctl-notify.lock")
+	}
+	return filepath.Join(os.TempDir(), fmt.Sprintf("keylightctl-notify-%d.lock", os.Getuid()))
+}
+
+// Lock blocks until it holds the lock file at path, so that concurrent
+// notifications play one after another instead of fighting over the lights.
+// The returned function releases the lock.
+func Lock(path string) (func(), error) {
+	file, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0o600)
+	if err != nil {
+		return nil, err
+	}
+	if err := lockFile(file); err != nil {
+		file.Close()
+		return nil, err
+	}
+	return func() {
+		unlockFile(file)
+		file.Close()
+	}, nil
+}
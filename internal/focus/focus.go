@@ -0,0 +1,224 @@
+// Package focus runs a Pomodoro-style focus timer that signals the changes
+// between focus and break through the lights.
+package focus
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/eckertalex/keylightctl/internal/effect"
+	"github.com/eckertalex/keylightctl/internal/keylight"
+)
+
+type Kind string
+
+const (
+	KindFocus Kind = "Focus"
+	KindBreak Kind = "Break"
+)
+
+type Phase struct {
+	Kind     Kind
+	Duration time.Duration
+	// Cycle is the focus cycle the phase belongs to, starting at 1.
+	Cycle int
+}
+
+// Plan returns the phases of the given number of cycles, each a focus
+// followed by a break. The last focus is not followed by a break.
+func Plan(focus, brk time.Duration, cycles int) []Phase {
+	var phases []Phase
+	for cycle := 1; cycle <= cycles; cycle++ {
+		phases = append(phases, Phase{Kind: KindFocus, Duration: focus, Cycle: cycle})
+		if cycle < cycles && brk > 0 {
+			phases = append(phases, Phase{Kind: KindBreak, Duration: brk, Cycle: cycle})
+		}
+	}
+	return phases
+}
+
+// Signals changes the lights when a phase starts. Focus keeps the lights as
+// they were when the timer started. The end of a focus pulses the lights
+// before they are dimmed for the break, the end of a break blinks them once
+// they are back, and the end of the timer breathes.
+type Signals struct {
+	Client keylight.Client
+	Lights []keylight.Light
+	// BreakBrightness is the brightness during breaks. Zero switches the
+	// lights off.
+	BreakBrightness int
+
+	// original is the state of each light when the timer started, by IP.
+	original map[string]keylight.LightDetail
+
+	// A single worker plays the signals one after another, so that a signal
+	// that is still playing when the next phase starts is not interleaved
+	// with the next one. A signal that has not started yet when the next one
+	// is queued is skipped, so that the lights do not fall behind the phases.
+	mu      sync.Mutex
+	pending *queuedSignal
+	closed  bool
+	// wake is signalled when a signal is queued or the worker is to stop.
+	wake chan struct{}
+	// done is closed once the worker has stopped.
+	done chan struct{}
+}
+
+type queuedSignal struct {
+	play   func() error
+	result chan error
+}
+
+// errClosed is the result of signals queued after Close.
+var errClosed = errors.New("signals are closed")
+
+// NewSignals records the state of the lights, which is kept during focus and
+// restored at the end. Lights whose state cannot be read are left out.
+func NewSignals(client keylight.Client, lights []keylight.Light, breakBrightness int) (*Signals, error) {
+	s := &Signals{
+		Client:          client,
+		BreakBrightness: breakBrightness,
+		original:        make(map[string]keylight.LightDetail, len(lights)),
+		wake:            make(chan struct{}, 1),
+		done:            make(chan struct{}),
+	}
+
+	var errs []error
+	for _, light := range lights {
+		status, err := client.GetLight(light.IP)
+		if err == nil && len(status.Lights) == 0 {
+			err = errors.New("empty status")
+		}
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", light.Name, err))
+			continue
+		}
+		s.Lights = append(s.Lights, light)
+		s.original[light.IP] = status.Lights[0]
+	}
+	if len(s.Lights) == 0 {
+		return nil, errors.Join(errs...)
+	}
+	go s.work()
+	return s, errors.Join(errs...)
+}
+
+func (s *Signals) work() {
+	defer close(s.done)
+	for {
+		s.mu.Lock()
+		next, closed := s.pending, s.closed
+		s.pending = nil
+		s.mu.Unlock()
+
+		switch {
+		case next != nil:
+			next.result <- next.play()
+		case closed:
+			return
+		default:
+			<-s.wake
+		}
+	}
+}
+
+func (s *Signals) wakeWorker() {
+	select {
+	case s.wake <- struct{}{}:
+	default:
+	}
+}
+
+// enqueue queues a signal without waiting for the ones before, and returns a
+// channel receiving its error once it has played. A skipped signal receives
+// nil.
+func (s *Signals) enqueue(signal func() error) <-chan error {
+	result := make(chan error, 1)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed {
+		result <- errClosed
+		return result
+	}
+	if s.pending != nil {
+		s.pending.result <- nil
+	}
+	s.pending = &queuedSignal{play: signal, result: result}
+	s.wakeWorker()
+	return result
+}
+
+// Start queues the signal of the start of a phase.
+func (s *Signals) Start(ctx context.Context, phase Phase) <-chan error {
+	return s.enqueue(func() error {
+		switch phase.Kind {
+		case KindBreak:
+			err := s.play(ctx, effect.Pulse, 2)
+			return errors.Join(err, s.update(func(d keylight.LightDetail) keylight.LightDetail {
+				if s.BreakBrightness == 0 {
+					d.On = 0
+					return d
+				}
+				d.On = 1
+				d.Brightness = s.BreakBrightness
+				return d
+			}))
+		default:
+			if err := s.Restore(); err != nil {
+				return err
+			}
+			return s.play(ctx, effect.Blink, 2)
+		}
+	})
+}
+
+// Finish queues the signal of the end of the timer, which restores the
+// lights.
+func (s *Signals) Finish(ctx context.Context) <-chan error {
+	return s.enqueue(func() error {
+		if err := s.Restore(); err != nil {
+			return err
+		}
+		return s.play(ctx, effect.Breathe, 1)
+	})
+}
+
+// Close waits for the queued signal to play and stops the worker. Signals
+// queued afterwards fail.
+func (s *Signals) Close() {
+	s.mu.Lock()
+	s.closed = true
+	s.mu.Unlock()
+	s.wakeWorker()
+	<-s.done
+}
+
+// Restore moves every light back to its state when the timer started. It
+// must not be called while a signal plays, unless from a signal.
+func (s *Signals) Restore() error {
+	return s.update(func(d keylight.LightDetail) keylight.LightDetail { return d })
+}
+
+// update moves every light to a state derived from its original state.
+func (s *Signals) update(f func(keylight.LightDetail) keylight.LightDetail) error {
+	var errs []error
+	for _, light := range s.Lights {
+		if _, err := s.Client.UpdateLight(light.IP, f(s.original[light.IP])); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", light.Name, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func (s *Signals) play(ctx context.Context, e effect.Effect, count int) error {
+	return effect.Play(ctx, s.Client, s.Lights, effect.Options{
+		Effect:     e,
+		Count:      count,
+		Period:     effect.DefaultPeriods[e],
+		Brightness: 100,
+	})
+}
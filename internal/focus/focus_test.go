@@ -0,0 +1,137 @@
+package focus
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/eckertalex/keylightctl/internal/keylight"
+	"github.com/eckertalex/keylightctl/internal/keylighttest"
+)
+
+const ip = "10.0.0.1"
+
+var original = keylight.LightDetail{On: 1, Brightness: 60, Temperature: 250}
+
+func newTestSignals(t *testing.T, breakBrightness int) (*Signals, *keylighttest.Lights) {
+	t.Helper()
+
+	lights := keylighttest.NewLights(map[string]keylight.LightDetail{ip: original})
+	s, err := NewSignals(lights, []keylight.Light{{Name: "Left", IP: ip}}, breakBrightness)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(s.Close)
+	return s, lights
+}
+
+// cancelled returns a context that is done, so that effects stop right away.
+func cancelled() context.Context {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	return ctx
+}
+
+func wait(t *testing.T, result <-chan error) {
+	t.Helper()
+	select {
+	case <-result:
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for the signal")
+	}
+}
+
+func TestBreakBrightness(t *testing.T) {
+	tests := []struct {
+		name       string
+		brightness int
+		want       keylight.LightDetail
+	}{
+		{"dimmed", 20, keylight.LightDetail{On: 1, Brightness: 20, Temperature: 250}},
+		{"off", 0, keylight.LightDetail{On: 0, Brightness: 60, Temperature: 250}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, lights := newTestSignals(t, tt.brightness)
+
+			wait(t, s.Start(cancelled(), Phase{Kind: KindBreak}))
+			if got := lights.Get(ip); got != tt.want {
+				t.Errorf("light is %+v during the break, want %+v", got, tt.want)
+			}
+
+			wait(t, s.Start(cancelled(), Phase{Kind: KindFocus}))
+			if got := lights.Get(ip); got != original {
+				t.Errorf("light is %+v during focus, want %+v", got, original)
+			}
+		})
+	}
+}
+
+func TestSignalsPlayInOrder(t *testing.T) {
+	s, lights := newTestSignals(t, 0)
+
+	ctx := cancelled()
+	var results []<-chan error
+	for range 20 {
+		results = append(results, s.Start(ctx, Phase{Kind: KindBreak}), s.Start(ctx, Phase{Kind: KindFocus}))
+	}
+	for _, result := range results {
+		wait(t, result)
+	}
+
+	// The focus queued last is played last.
+	if got := lights.Get(ip); got != original {
+		t.Errorf("light is %+v, want %+v", got, original)
+	}
+}
+
+func TestSignalsDoNotBlock(t *testing.T) {
+	s, lights := newTestSignals(t, 0)
+
+	// The first signal hangs on the lights until released.
+	release := make(chan struct{})
+	lights.AfterUpdate = func(string, keylight.LightDetail) { <-release }
+	first := s.Start(cancelled(), Phase{Kind: KindFocus})
+
+	queued := make(chan []<-chan error)
+	go func() {
+		var results []<-chan error
+		for range 100 {
+			results = append(results, s.Start(cancelled(), Phase{Kind: KindFocus}))
+		}
+		queued <- results
+	}()
+	var results []<-chan error
+	select {
+	case results = <-queued:
+	case <-time.After(5 * time.Second):
+		close(release)
+		t.Fatal("queuing signals blocked while one was playing")
+	}
+
+	// Only the last signal queued meanwhile plays, the others are skipped.
+	for _, result := range results[:len(results)-1] {
+		if err := <-result; err != nil {
+			t.Errorf("skipped signal failed: %v", err)
+		}
+	}
+	updates := len(lights.Updates(ip))
+	close(release)
+	wait(t, first)
+	wait(t, results[len(results)-1])
+	if len(lights.Updates(ip)) == updates {
+		t.Error("the last signal did not play")
+	}
+}
+
+func TestStartAfterClose(t *testing.T) {
+	s, lights := newTestSignals(t, 0)
+	s.Close()
+
+	if err := <-s.Start(cancelled(), Phase{Kind: KindBreak}); err == nil {
+		t.Error("expected an error for a signal queued after Close")
+	}
+	if updates := lights.Updates(ip); len(updates) != 0 {
+		t.Errorf("lights were updated after Close: %+v", updates)
+	}
+}
@@ -0,0 +1,167 @@
+package focus
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/bubbles/progress"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// tick is the interval at which the countdown is updated.
+const tick = 200 * time.Millisecond
+
+type tickMsg time.Time
+
+type signalMsg struct {
+	err   error
+	final bool
+}
+
+// Model is the terminal countdown of a timer. Space pauses and resumes it,
+// q or Ctrl+C stops it.
+type Model struct {
+	phases  []Phase
+	current int
+	elapsed time.Duration
+	last    time.Time
+	paused  bool
+	done    bool
+	err     error
+
+	signals *Signals
+	ctx     context.Context
+
+	bar progress.Model
+}
+
+// Run shows the countdown until every phase is over or the timer is stopped.
+// Signals still playing are cancelled and the lights restored before it
+// returns.
+func Run(phases []Phase, signals *Signals) error {
+	ctx, cancel := context.WithCancel(context.Background())
+	m := Model{
+		phases:  phases,
+		signals: signals,
+		ctx:     ctx,
+		bar:     progress.New(progress.WithDefaultGradient(), progress.WithWidth(40), progress.WithoutPercentage()),
+	}
+
+	_, err := tea.NewProgram(m).Run()
+	cancel()
+	signals.Close()
+	if restoreErr := signals.Restore(); restoreErr != nil && err == nil {
+		err = fmt.Errorf("restoring: %w", restoreErr)
+	}
+	return err
+}
+
+func (m Model) Init() tea.Cmd {
+	return tea.Batch(tickNow, m.signal(m.phases[0]))
+}
+
+func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.KeyMsg:
+		switch msg.String() {
+		case " ", "p":
+			m.paused = !m.paused
+		case "q", "esc", "ctrl+c":
+			return m, tea.Quit
+		}
+	case tickMsg:
+		t := time.Time(msg)
+		if !m.last.IsZero() && !m.paused && !m.done {
+			m.elapsed += t.Sub(m.last)
+		}
+		m.last = t
+
+		if m.done || m.elapsed < m.phases[m.current].Duration {
+			return m, tickAfter()
+		}
+		m.elapsed = 0
+		m.current++
+		if m.current == len(m.phases) {
+			m.current--
+			m.elapsed = m.phases[m.current].Duration
+			m.done = true
+			return m, m.finish()
+		}
+		return m, tea.Batch(tickAfter(), m.signal(m.phases[m.current]))
+	case signalMsg:
+		if msg.err != nil {
+			m.err = msg.err
+		}
+		if msg.final {
+			return m, tea.Quit
+		}
+	case tea.WindowSizeMsg:
+		m.bar.Width = min(40, max(msg.Width-30, 10))
+	}
+	return m, nil
+}
+
+func (m Model) View() string {
+	phase := m.phases[m.current]
+	cycles := m.phases[len(m.phases)-1].Cycle
+	remaining := max(phase.Duration-m.elapsed, 0)
+
+	var b strings.Builder
+	style := lipgloss.NewStyle().Bold(true)
+	if phase.Kind == KindBreak {
+		style = style.Foreground(lipgloss.Color("2"))
+	}
+	fmt.Fprintf(&b, "%s %d/%d  %s  %s", style.Render(string(phase.Kind)), phase.Cycle, cycles,
+		formatRemaining(remaining), m.bar.ViewAs(float64(m.elapsed)/float64(phase.Duration)))
+
+	switch {
+	case m.done:
+		b.WriteString("  Done")
+	case m.paused:
+		b.WriteString("  Paused")
+	}
+	b.WriteString("\n")
+	if m.err != nil {
+		b.WriteString(lipgloss.NewStyle().Foreground(lipgloss.Color("1")).Render(m.err.Error()) + "\n")
+	}
+	b.WriteString(lipgloss.NewStyle().Faint(true).Render("space: pause/resume • q: quit") + "\n")
+	return b.String()
+}
+
+func formatRemaining(d time.Duration) string {
+	d = d.Round(time.Second)
+	if d >= time.Hour {
+		return fmt.Sprintf("%d:%02d:%02d", int(d.Hours()), int(d.Minutes())%60, int(d.Seconds())%60)
+	}
+	return fmt.Sprintf("%02d:%02d", int(d.Minutes()), int(d.Seconds())%60)
+}
+
+// signal queues the signal of a phase right away, rather than when the
+// program gets to the returned command, so that signals play in the order of
+// the phases and Run can wait for them even if the program quits first.
+func (m Model) signal(phase Phase) tea.Cmd {
+	return waitSignal(m.signals.Start(m.ctx, phase), false)
+}
+
+// finish queues the signal of the end of the timer, after which the program
+// quits.
+func (m Model) finish() tea.Cmd {
+	return waitSignal(m.signals.Finish(m.ctx), true)
+}
+
+func waitSignal(result <-chan error, final bool) tea.Cmd {
+	return func() tea.Msg {
+		return signalMsg{err: <-result, final: final}
+	}
+}
+
+func tickNow() tea.Msg {
+	return tickMsg(time.Now())
+}
+
+func tickAfter() tea.Cmd {
+	return tea.Tick(tick, func(t time.Time) tea.Msg { return tickMsg(t) })
+}
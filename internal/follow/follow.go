@@ -0,0 +1,189 @@
+// Package follow mirrors the state of a leader light to follower lights.
+package follow
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"math"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/eckertalex/keylightctl/internal/keylight"
+	"github.com/eckertalex/keylightctl/internal/watch"
+)
+
+// Follower mirrors a leader, shifted by a brightness offset in percent and a
+// temperature offset in Kelvin.
+type Follower struct {
+	Light             keylight.Light
+	BrightnessOffset  int
+	TemperatureOffset int
+}
+
+// Detail returns the state of the follower for the state of its leader.
+func (f Follower) Detail(leader keylight.LightDetail) keylight.LightDetail {
+	detail := leader
+	detail.Brightness = min(max(leader.Brightness+f.BrightnessOffset, 0), 100)
+	if f.TemperatureOffset != 0 && leader.Temperature > 0 {
+		kelvin := int(math.Round(1000000/float64(leader.Temperature))) + f.TemperatureOffset
+		detail.Temperature = keylight.KelvinToMiredExact(max(kelvin, 1))
+	}
+	return detail
+}
+
+// Followers returns the followers of every leader configured through the
+// follows key, by leader name.
+func Followers(lights []keylight.LightConfig) map[string][]Follower {
+	followers := make(map[string][]Follower)
+	for _, light := range lights {
+		if light.Follows == "" {
+			continue
+		}
+		followers[light.Follows] = append(followers[light.Follows], Follower{
+			Light:             light.Light,
+			BrightnessOffset:  light.FollowBrightnessOffset,
+			TemperatureOffset: light.FollowTemperatureOffset,
+		})
+	}
+	return followers
+}
+
+// Validate checks that every light follows another configured light, and
+// that no light follows itself, directly or through other lights, which
+// would propagate changes in a loop.
+func Validate(lights []keylight.LightConfig) error {
+	follows := make(map[string]string, len(lights))
+	for _, light := range lights {
+		if light.Follows == "" {
+			continue
+		}
+		if !slices.ContainsFunc(lights, func(l keylight.LightConfig) bool { return l.Name == light.Follows }) {
+			return fmt.Errorf("light '%s': followed light '%s' not found", light.Name, light.Follows)
+		}
+		follows[light.Name] = light.Follows
+	}
+
+	for _, light := range lights {
+		path := []string{light.Name}
+		for next, ok := follows[light.Name]; ok; next, ok = follows[next] {
+			// Every light follows at most one other, so the path either
+			// ends or runs into a cycle, which may not include light.
+			if i := slices.Index(path, next); i >= 0 {
+				return fmt.Errorf("follow cycle: %s -> %s", strings.Join(path[i:], " -> "), next)
+			}
+			path = append(path, next)
+		}
+	}
+	return nil
+}
+
+// Mirror propagates the state of a leader to its followers. States set while
+// an earlier one is still being propagated are coalesced, so that the
+// followers end up with the latest state of the leader.
+type Mirror struct {
+	Followers []Follower
+	// Update moves a follower to a state.
+	Update func(f Follower, detail keylight.LightDetail) error
+
+	mu      sync.Mutex
+	pending *keylight.LightDetail
+	wake    chan struct{}
+}
+
+func NewMirror(followers []Follower, update func(f Follower, detail keylight.LightDetail) error) *Mirror {
+	return &Mirror{
+		Followers: followers,
+		Update:    update,
+		wake:      make(chan struct{}, 1),
+	}
+}
+
+// Set propagates a new state of the leader. It does not block.
+func (m *Mirror) Set(leader keylight.LightDetail) {
+	m.mu.Lock()
+	m.pending = &leader
+	m.mu.Unlock()
+
+	select {
+	case m.wake <- struct{}{}:
+	default:
+	}
+}
+
+// Run propagates the states of the leader until ctx is done.
+func (m *Mirror) Run(ctx context.Context) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-m.wake:
+		}
+
+		m.mu.Lock()
+		leader := *m.pending
+		m.mu.Unlock()
+
+		var wg sync.WaitGroup
+		for _, f := range m.Followers {
+			wg.Add(1)
+			go func(f Follower) {
+				defer wg.Done()
+				if err := m.Update(f, f.Detail(leader)); err != nil {
+					log.Printf("Light '%s' failed to follow: %v", f.Light.Name, err)
+				}
+			}(f)
+		}
+		wg.Wait()
+	}
+}
+
+// Runner polls the leaders and mirrors their changes, made from any app, to
+// their followers.
+type Runner struct {
+	Client    keylight.Client
+	Leaders   []keylight.Light
+	Followers map[string][]Follower
+	Interval  time.Duration
+}
+
+func NewRunner(client keylight.Client, leaders []keylight.Light, followers map[string][]Follower, interval time.Duration) *Runner {
+	return &Runner{
+		Client:    client,
+		Leaders:   leaders,
+		Followers: followers,
+		Interval:  interval,
+	}
+}
+
+// Run mirrors the leaders until ctx is done. Followers are updated when a
+// leader comes online and whenever its state changes.
+func (r *Runner) Run(ctx context.Context) {
+	mirrors := make(map[string]*Mirror, len(r.Leaders))
+	var wg sync.WaitGroup
+	for _, leader := range r.Leaders {
+		mirror := NewMirror(r.Followers[leader.Name], func(f Follower, detail keylight.LightDetail) error {
+			_, err := r.Client.UpdateLight(f.Light.IP, detail)
+			return err
+		})
+		mirrors[leader.Name] = mirror
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			mirror.Run(ctx)
+		}()
+	}
+
+	watch.New(r.Client, r.Leaders, r.Interval).Run(ctx, func(event watch.Event) {
+		switch event.Type {
+		case watch.EventOnline, watch.EventChanged:
+			mirrors[event.Light.Name].Set(event.Current)
+		case watch.EventOffline:
+			log.Printf("Leader '%s' is offline: %v", event.Light.Name, event.Err)
+		}
+	})
+	wg.Wait()
+}
@@ -0,0 +1,129 @@
+package follow
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/eckertalex/keylightctl/internal/keylight"
+)
+
+func lightConfig(name, follows string) keylight.LightConfig {
+	return keylight.LightConfig{Light: keylight.Light{Name: name}, Follows: follows}
+}
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name   string
+		lights []keylight.LightConfig
+		err    string
+	}{
+		{"no follows", []keylight.LightConfig{lightConfig("A", ""), lightConfig("B", "")}, ""},
+		{"chain", []keylight.LightConfig{lightConfig("A", "B"), lightConfig("B", "C"), lightConfig("C", "")}, ""},
+		{"shared leader", []keylight.LightConfig{lightConfig("A", "C"), lightConfig("B", "C"), lightConfig("C", "")}, ""},
+		{"missing leader", []keylight.LightConfig{lightConfig("A", "Z")}, "followed light 'Z' not found"},
+		{"itself", []keylight.LightConfig{lightConfig("A", "A")}, "follow cycle: A -> A"},
+		{"each other", []keylight.LightConfig{lightConfig("A", "B"), lightConfig("B", "A")}, "follow cycle: A -> B -> A"},
+		// A leads into a cycle it is not part of.
+		{"into a cycle", []keylight.LightConfig{lightConfig("A", "B"), lightConfig("B", "C"), lightConfig("C", "B")}, "follow cycle: B -> C -> B"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := Validate(tt.lights)
+			switch {
+			case tt.err == "" && err != nil:
+				t.Errorf("unexpected error: %v", err)
+			case tt.err != "" && (err == nil || !strings.Contains(err.Error(), tt.err)):
+				t.Errorf("got error %v, want %q", err, tt.err)
+			}
+		})
+	}
+}
+
+func TestFollowerDetail(t *testing.T) {
+	leader := keylight.LightDetail{On: 1, Brightness: 50, Temperature: 200}
+	tests := []struct {
+		name     string
+		follower Follower
+		leader   keylight.LightDetail
+		want     keylight.LightDetail
+	}{
+		{"no offsets", Follower{}, leader, leader},
+		{"brightness offset", Follower{BrightnessOffset: 20}, leader, keylight.LightDetail{On: 1, Brightness: 70, Temperature: 200}},
+		{"brightness clamped to 100", Follower{BrightnessOffset: 80}, leader, keylight.LightDetail{On: 1, Brightness: 100, Temperature: 200}},
+		{"brightness clamped to 0", Follower{BrightnessOffset: -80}, leader, keylight.LightDetail{On: 1, Brightness: 0, Temperature: 200}},
+		// 5000 K + 1000 K is 6000 K, about 167 mired.
+		{"temperature offset", Follower{TemperatureOffset: 1000}, leader, keylight.LightDetail{On: 1, Brightness: 50, Temperature: 167}},
+		{"temperature clamped to coolest", Follower{TemperatureOffset: 5000}, leader, keylight.LightDetail{On: 1, Brightness: 50, Temperature: keylight.MinMired}},
+		{"temperature clamped to warmest", Follower{TemperatureOffset: -5000}, leader, keylight.LightDetail{On: 1, Brightness: 50, Temperature: keylight.MaxMired}},
+		{"unknown temperature", Follower{TemperatureOffset: 1000}, keylight.LightDetail{On: 1, Brightness: 50}, keylight.LightDetail{On: 1, Brightness: 50}},
+		{"off", Follower{BrightnessOffset: 10}, keylight.LightDetail{On: 0, Brightness: 50, Temperature: 200}, keylight.LightDetail{On: 0, Brightness: 60, Temperature: 200}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.follower.Detail(tt.leader); got != tt.want {
+				t.Errorf("Detail(%+v) = %+v, want %+v", tt.leader, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMirrorCoalesces(t *testing.T) {
+	var (
+		mu      sync.Mutex
+		updates []keylight.LightDetail
+	)
+	started := make(chan struct{}, 10)
+	release := make(chan struct{})
+	mirror := NewMirror([]Follower{{Light: keylight.Light{Name: "Right"}}}, func(_ Follower, detail keylight.LightDetail) error {
+		mu.Lock()
+		updates = append(updates, detail)
+		mu.Unlock()
+		started <- struct{}{}
+		<-release
+		return nil
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		mirror.Run(ctx)
+	}()
+
+	waitStarted := func() {
+		t.Helper()
+		select {
+		case <-started:
+		case <-time.After(5 * time.Second):
+			t.Fatal("timed out waiting for an update")
+		}
+	}
+
+	mirror.Set(keylight.LightDetail{On: 1, Brightness: 10})
+	waitStarted()
+	// The states set while the first one propagates are coalesced.
+	for brightness := 20; brightness <= 50; brightness += 10 {
+		mirror.Set(keylight.LightDetail{On: 1, Brightness: brightness})
+	}
+	release <- struct{}{}
+	waitStarted()
+	release <- struct{}{}
+
+	cancel()
+	<-done
+
+	want := []keylight.LightDetail{{On: 1, Brightness: 10}, {On: 1, Brightness: 50}}
+	mu.Lock()
+	defer mu.Unlock()
+	if len(updates) != len(want) {
+		t.Fatalf("got updates %+v, want %+v", updates, want)
+	}
+	for i := range want {
+		if updates[i] != want[i] {
+			t.Errorf("update %d is %+v, want %+v", i+1, updates[i], want[i])
+		}
+	}
+}
@@ -9,24 +9,63 @@ import (
 	"time"
 )
 
+// Client reads and updates the state of the light at the given address.
+// It is implemented by Controller.
+type Client interface {
+	GetLight(ip string) (*LightStatus, error)
+	UpdateLight(ip string, settings LightDetail) (*LightStatus, error)
+}
+
+// Operation names a kind of request made to a light.
+type Operation string
+
+const (
+	OperationGet    Operation = "get"
+	OperationUpdate Operation = "update"
+)
+
+// Observer is notified of every request a Controller makes, for example to
+// record metrics. It must be safe for concurrent use.
+type Observer interface {
+	// ObserveRequest is called after every attempt, with the error of the
+	// attempt if it failed.
+	ObserveRequest(ip string, op Operation, duration time.Duration, err error)
+	// ObserveRetry is called when a failed attempt is retried.
+	ObserveRetry(ip string, op Operation, err error)
+}
+
 type Controller struct {
 	client     *http.Client
 	maxRetries int
 	delay      time.Duration
+	observer   Observer
+}
+
+type ControllerOption func(*Controller)
+
+// WithObserver makes the controller report every request to o.
+func WithObserver(o Observer) ControllerOption {
+	return func(c *Controller) {
+		c.observer = o
+	}
 }
 
-func NewController() *Controller {
-	return &Controller{
+func NewController(opts ...ControllerOption) *Controller {
+	c := &Controller{
 		client: &http.Client{
 			Timeout: 3 * time.Second,
 		},
 		maxRetries: 3,
 		delay:      100 * time.Millisecond,
 	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
 }
 
 func (c *Controller) GetLight(ip string) (*LightStatus, error) {
-	return retryHTTPCall(c.maxRetries, c.delay, func() (*LightStatus, error) {
+	return c.retry(ip, OperationGet, func() (*LightStatus, error) {
 		resp, err := c.client.Get(getLightsURL(ip))
 		if err != nil {
 			return nil, fmt.Errorf("request failed: %w", err)
@@ -48,7 +87,7 @@ func (c *Controller) GetLight(ip string) (*LightStatus, error) {
 }
 
 func (c *Controller) UpdateLight(ip string, settings LightDetail) (*LightStatus, error) {
-	return retryHTTPCall(c.maxRetries, c.delay, func() (*LightStatus, error) {
+	return c.retry(ip, OperationUpdate, func() (*LightStatus, error) {
 		payload := LightStatus{
 			Lights: []LightDetail{settings},
 		}
@@ -74,7 +113,7 @@ func (c *Controller) UpdateLight(ip string, settings LightDetail) (*LightStatus,
 
 		body, err := io.ReadAll(resp.Body)
 		if resp.StatusCode != http.StatusOK {
-			return nil, fmt.Errorf("unexpected status code %d: %s", resp.StatusCode, string(body))
+			return nil, &StatusError{StatusCode: resp.StatusCode, Body: string(body)}
 		}
 		if err != nil {
 			return nil, fmt.Errorf("reading response failed: %w", err)
@@ -89,6 +128,26 @@ func (c *Controller) UpdateLight(ip string, settings LightDetail) (*LightStatus,
 	})
 }
 
+// retry calls f with retries, reporting every attempt to the observer.
+func (c *Controller) retry(ip string, op Operation, f func() (*LightStatus, error)) (*LightStatus, error) {
+	if c.observer == nil {
+		return retryHTTPCall(c.maxRetries, c.delay, f)
+	}
+
+	attempt := 0
+	return retryHTTPCall(c.maxRetries, c.delay, func() (*LightStatus, error) {
+		start := time.Now()
+		status, err := f()
+		c.observer.ObserveRequest(ip, op, time.Since(start), err)
+
+		attempt++
+		if err != nil && attempt < c.maxRetries {
+			c.observer.ObserveRetry(ip, op, err)
+		}
+		return status, err
+	})
+}
+
 func retryHTTPCall(attempts int, initialDelay time.Duration, f func() (*LightStatus, error)) (*LightStatus, error) {
 	var lastErr error
 	delay := initialDelay
@@ -0,0 +1,66 @@
+package keylight
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"syscall"
+)
+
+// StatusError is returned when a light answers with an unexpected HTTP
+// status.
+type StatusError struct {
+	StatusCode int
+	Body       string
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("unexpected status code %d: %s", e.StatusCode, e.Body)
+}
+
+// ErrorClass is a coarse classification of a request error, suitable as a
+// metric label.
+type ErrorClass string
+
+const (
+	ErrorTimeout     ErrorClass = "timeout"
+	ErrorRefused     ErrorClass = "refused"
+	ErrorUnreachable ErrorClass = "unreachable"
+	ErrorDNS         ErrorClass = "dns"
+	ErrorConnection  ErrorClass = "connection"
+	ErrorStatus      ErrorClass = "status"
+	ErrorResponse    ErrorClass = "response"
+	ErrorOther       ErrorClass = "other"
+)
+
+// ClassifyError returns the class of an error returned by a Controller.
+func ClassifyError(err error) ErrorClass {
+	var (
+		statusErr *StatusError
+		dnsErr    *net.DNSError
+		syntaxErr *json.SyntaxError
+		typeErr   *json.UnmarshalTypeError
+		netErr    net.Error
+		opErr     *net.OpError
+	)
+	switch {
+	case errors.As(err, &statusErr):
+		return ErrorStatus
+	case errors.As(err, &syntaxErr), errors.As(err, &typeErr):
+		return ErrorResponse
+	case errors.As(err, &dnsErr):
+		return ErrorDNS
+	case errors.Is(err, context.DeadlineExceeded), errors.As(err, &netErr) && netErr.Timeout():
+		return ErrorTimeout
+	case errors.Is(err, syscall.ECONNREFUSED):
+		return ErrorRefused
+	case errors.Is(err, syscall.EHOSTUNREACH), errors.Is(err, syscall.ENETUNREACH):
+		return ErrorUnreachable
+	case errors.As(err, &opErr), errors.Is(err, io.EOF), errors.Is(err, io.ErrUnexpectedEOF), errors.Is(err, syscall.ECONNRESET):
+		return ErrorConnection
+	}
+	return ErrorOther
+}
@@ -0,0 +1,79 @@
+package keylight
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+func FindGroup(groups []GroupConfig, name string) *GroupConfig {
+	for i := range groups {
+		if groups[i].Name == name {
+			return &groups[i]
+		}
+	}
+	return nil
+}
+
+// ExpandGroup returns the names of all lights in the named group, including
+// the lights of nested groups, in order of first appearance.
+func ExpandGroup(groups []GroupConfig, name string) ([]string, error) {
+	var names []string
+	if err := expandGroup(groups, name, nil, &names); err != nil {
+		return nil, err
+	}
+	return names, nil
+}
+
+func expandGroup(groups []GroupConfig, name string, path []string, names *[]string) error {
+	if slices.Contains(path, name) {
+		return fmt.Errorf("group cycle: %s -> %s", strings.Join(path, " -> "), name)
+	}
+
+	group := FindGroup(groups, name)
+	if group == nil {
+		return fmt.Errorf("group '%s' not found", name)
+	}
+
+	for _, light := range group.Lights {
+		if !slices.Contains(*names, light) {
+			*names = append(*names, light)
+		}
+	}
+
+	path = append(path, name)
+	for _, nested := range group.Groups {
+		if err := expandGroup(groups, nested, path, names); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ValidateGroups checks that every group has a unique name, only references
+// configured lights and existing groups, and does not contain itself.
+func ValidateGroups(groups []GroupConfig, lights []LightConfig) error {
+	seen := make(map[string]bool, len(groups))
+	for _, group := range groups {
+		if group.Name == "" {
+			return fmt.Errorf("group without a name")
+		}
+		if seen[group.Name] {
+			return fmt.Errorf("duplicate group '%s'", group.Name)
+		}
+		seen[group.Name] = true
+
+		for _, light := range group.Lights {
+			if !slices.ContainsFunc(lights, func(l LightConfig) bool { return l.Name == light }) {
+				return fmt.Errorf("group '%s': light '%s' not found", group.Name, light)
+			}
+		}
+	}
+
+	for _, group := range groups {
+		if _, err := ExpandGroup(groups, group.Name); err != nil {
+			return fmt.Errorf("group '%s': %w", group.Name, err)
+		}
+	}
+	return nil
+}
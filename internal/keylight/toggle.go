@@ -0,0 +1,39 @@
+package keylight
+
+import (
+	"fmt"
+	"slices"
+)
+
+type ToggleMode string
+
+const (
+	// ToggleAnyOnTurnsAllOff turns every light off if any of them is on, and
+	// on otherwise, so that mixed groups converge.
+	ToggleAnyOnTurnsAllOff ToggleMode = "any-on-turns-all-off"
+	// ToggleIndependent flips each light on its own.
+	ToggleIndependent ToggleMode = "independent"
+)
+
+var ToggleModes = []ToggleMode{ToggleAnyOnTurnsAllOff, ToggleIndependent}
+
+func ParseToggleMode(mode string) (ToggleMode, error) {
+	if slices.Contains(ToggleModes, ToggleMode(mode)) {
+		return ToggleMode(mode), nil
+	}
+	return "", fmt.Errorf("unknown toggle mode '%s', expected one of %v", mode, ToggleModes)
+}
+
+// Toggle returns the new power state for each light, given the current ones.
+func Toggle(mode ToggleMode, on []bool) []bool {
+	next := make([]bool, len(on))
+	anyOn := slices.Contains(on, true)
+	for i := range on {
+		if mode == ToggleIndependent {
+			next[i] = !on[i]
+		} else {
+			next[i] = !anyOn
+		}
+	}
+	return next
+}
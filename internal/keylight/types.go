@@ -1,5 +1,16 @@
 package keylight
 
+import (
+	"fmt"
+	"math"
+)
+
+// The temperature range the lights accept, in mired.
+const (
+	MinMired = 143
+	MaxMired = 344
+)
+
 type LightDetail struct {
 	On          int `json:"on"`
 	Brightness  int `json:"brightness,omitempty"`
@@ -18,6 +29,39 @@ type Light struct {
 
 type LightConfig struct {
 	Light `mapstructure:",squash"`
+	Tags  []string `mapstructure:"tags"`
+	// Follows names a light whose state this light mirrors, shifted by the
+	// brightness and temperature offsets.
+	Follows                 string `mapstructure:"follows"`
+	FollowBrightnessOffset  int    `mapstructure:"follow_brightness_offset"`
+	FollowTemperatureOffset int    `mapstructure:"follow_temperature_offset"`
+}
+
+type GroupConfig struct {
+	Name   string   `mapstructure:"name"`
+	Lights []string `mapstructure:"lights"`
+	Groups []string `mapstructure:"groups"`
+}
+
+func ValidateBrightness(brightness int) error {
+	if brightness < 0 || brightness > 100 {
+		return fmt.Errorf("brightness must be between 0 and 100")
+	}
+	return nil
+}
+
+func ValidateTemperature(temperature int) error {
+	if temperature < 2900 || temperature > 7000 {
+		return fmt.Errorf("temperature must be between 2900K and 7000K")
+	}
+	return nil
+}
+
+func ValidateMired(mired int) error {
+	if mired < MinMired || mired > MaxMired {
+		return fmt.Errorf("temperature must be between %d and %d mired", MinMired, MaxMired)
+	}
+	return nil
 }
 
 func MiredToKelvin(mired int) int {
@@ -31,6 +75,19 @@ func KelvinToMired(kelvin int) int {
 	return roundToNearest50(1000000 / kelvin)
 }
 
+// KelvinToMiredExact converts to the nearest mired value the lights accept,
+// without rounding to steps of 50, for gradual changes of temperature.
+func KelvinToMiredExact(kelvin int) int {
+	mired := int(math.Round(1000000 / float64(kelvin)))
+	return min(max(mired, MinMired), MaxMired)
+}
+
+// MiredToKelvinExact converts to Kelvin without rounding to steps of 50, so
+// that converting back with KelvinToMiredExact gives the same mired value.
+func MiredToKelvinExact(mired int) int {
+	return int(math.Round(1000000 / float64(mired)))
+}
+
 func roundToNearest50(n int) int {
 	return (n + 25) / 50 * 50
 }
@@ -0,0 +1,141 @@
+// Package keylighttest provides fake lights and a fake clock for tests.
+package keylighttest
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/eckertalex/keylightctl/internal/keylight"
+)
+
+// Lights is a keylight.Client keeping the state of every light in memory,
+// by IP. Lights not set up are unreachable.
+type Lights struct {
+	// AfterUpdate, if set, is called after every update, for example to
+	// change the light from another app.
+	AfterUpdate func(ip string, settings keylight.LightDetail)
+
+	mu      sync.Mutex
+	states  map[string]keylight.LightDetail
+	errs    map[string]error
+	updates map[string][]keylight.LightDetail
+}
+
+// NewLights returns lights in the given states, by IP.
+func NewLights(states map[string]keylight.LightDetail) *Lights {
+	l := &Lights{
+		states:  make(map[string]keylight.LightDetail, len(states)),
+		errs:    make(map[string]error),
+		updates: make(map[string][]keylight.LightDetail),
+	}
+	for ip, detail := range states {
+		l.states[ip] = detail
+	}
+	return l
+}
+
+func (l *Lights) GetLight(ip string) (*keylight.LightStatus, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	detail, err := l.lookup(ip)
+	if err != nil {
+		return nil, err
+	}
+	return &keylight.LightStatus{Lights: []keylight.LightDetail{detail}, NumberOfLights: 1}, nil
+}
+
+func (l *Lights) UpdateLight(ip string, settings keylight.LightDetail) (*keylight.LightStatus, error) {
+	l.mu.Lock()
+	if _, err := l.lookup(ip); err != nil {
+		l.mu.Unlock()
+		return nil, err
+	}
+	l.states[ip] = settings
+	l.updates[ip] = append(l.updates[ip], settings)
+	l.mu.Unlock()
+
+	if l.AfterUpdate != nil {
+		l.AfterUpdate(ip, settings)
+	}
+	return &keylight.LightStatus{Lights: []keylight.LightDetail{settings}, NumberOfLights: 1}, nil
+}
+
+func (l *Lights) lookup(ip string) (keylight.LightDetail, error) {
+	if err := l.errs[ip]; err != nil {
+		return keylight.LightDetail{}, err
+	}
+	detail, ok := l.states[ip]
+	if !ok {
+		return keylight.LightDetail{}, fmt.Errorf("no light at %s", ip)
+	}
+	return detail, nil
+}
+
+// Get returns the state of a light.
+func (l *Lights) Get(ip string) keylight.LightDetail {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.states[ip]
+}
+
+// Set changes the state of a light without recording an update, like
+// another app would.
+func (l *Lights) Set(ip string, detail keylight.LightDetail) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.states[ip] = detail
+}
+
+// Fail makes every request to a light fail with err, or succeed again if err
+// is nil.
+func (l *Lights) Fail(ip string, err error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.errs[ip] = err
+}
+
+// Updates returns the updates a light received, in order.
+func (l *Lights) Updates(ip string) []keylight.LightDetail {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return append([]keylight.LightDetail(nil), l.updates[ip]...)
+}
+
+// Clock is a clock.Clock whose time only moves when it is waited for or
+// advanced. After moves it forward by the duration waited for, so that code
+// waiting on it runs through without sleeping.
+type Clock struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+func NewClock(now time.Time) *Clock {
+	return &Clock{now: now}
+}
+
+func (c *Clock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *Clock) After(d time.Duration) <-chan time.Time {
+	ch := make(chan time.Time, 1)
+	ch <- c.Advance(d)
+	return ch
+}
+
+// Advance moves the clock forward by d and returns the new time.
+func (c *Clock) Advance(d time.Duration) time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(d)
+	return c.now
+}
+
+// Date returns a time on the given day of January 2026, in UTC.
+func Date(day, hour, minute int) time.Time {
+	return time.Date(2026, time.January, day, hour, minute, 0, 0, time.UTC)
+}
@@ -0,0 +1,120 @@
+// Package metrics exposes the state of the lights and the health of the
+// requests made to them as Prometheus metrics.
+package metrics
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/eckertalex/keylightctl/internal/keylight"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// Metrics holds the metrics of a set of lights. It implements
+// keylight.Observer to record the requests made to them.
+type Metrics struct {
+	registry *prometheus.Registry
+	// names maps the address of every light to its name.
+	names map[string]string
+
+	up          *prometheus.GaugeVec
+	on          *prometheus.GaugeVec
+	brightness  *prometheus.GaugeVec
+	temperature *prometheus.GaugeVec
+	duration    *prometheus.HistogramVec
+	errors      *prometheus.CounterVec
+	retries     *prometheus.CounterVec
+}
+
+func New(lights []keylight.Light) *Metrics {
+	m := &Metrics{
+		registry: prometheus.NewRegistry(),
+		names:    make(map[string]string, len(lights)),
+		up: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Name: "keylight_up",
+			Help: "Whether the light answered its last request (1) or not (0).",
+		}, []string{"light"}),
+		on: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Name: "keylight_on",
+			Help: "Whether the light is on (1) or off (0).",
+		}, []string{"light"}),
+		brightness: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Name: "keylight_brightness_percent",
+			Help: "Brightness of the light in percent.",
+		}, []string{"light"}),
+		temperature: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Name: "keylight_temperature_kelvin",
+			Help: "Color temperature of the light in Kelvin.",
+		}, []string{"light"}),
+		duration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "keylight_request_duration_seconds",
+			Help:    "Duration of every request attempt made to a light, including failed ones.",
+			Buckets: []float64{.005, .01, .025, .05, .1, .25, .5, 1, 2.5},
+		}, []string{"light", "operation"}),
+		errors: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "keylight_request_errors_total",
+			Help: "Failed request attempts made to a light, by error class.",
+		}, []string{"light", "operation", "class"}),
+		retries: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "keylight_request_retries_total",
+			Help: "Requests to a light that were retried, by the error class of the failed attempt.",
+		}, []string{"light", "operation", "class"}),
+	}
+
+	for _, light := range lights {
+		m.names[light.IP] = light.Name
+	}
+
+	m.registry.MustRegister(
+		m.up, m.on, m.brightness, m.temperature,
+		m.duration, m.errors, m.retries,
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+	)
+	return m
+}
+
+// Handler serves the metrics in the Prometheus exposition format.
+func (m *Metrics) Handler() http.Handler {
+	return promhttp.HandlerFor(m.registry, promhttp.HandlerOpts{})
+}
+
+// SetState records the state of an online light.
+func (m *Metrics) SetState(name string, detail keylight.LightDetail) {
+	m.up.WithLabelValues(name).Set(1)
+	m.on.WithLabelValues(name).Set(float64(detail.On))
+	m.brightness.WithLabelValues(name).Set(float64(detail.Brightness))
+	if detail.Temperature != 0 {
+		m.temperature.WithLabelValues(name).Set(float64(keylight.MiredToKelvin(detail.Temperature)))
+	}
+}
+
+// SetOffline records that a light stopped answering. Its last known state is
+// dropped, so that graphs show a gap rather than a stale value.
+func (m *Metrics) SetOffline(name string) {
+	m.up.WithLabelValues(name).Set(0)
+	m.on.DeleteLabelValues(name)
+	m.brightness.DeleteLabelValues(name)
+	m.temperature.DeleteLabelValues(name)
+}
+
+func (m *Metrics) ObserveRequest(ip string, op keylight.Operation, duration time.Duration, err error) {
+	name := m.name(ip)
+	m.duration.WithLabelValues(name, string(op)).Observe(duration.Seconds())
+	if err != nil {
+		m.errors.WithLabelValues(name, string(op), string(keylight.ClassifyError(err))).Inc()
+	}
+}
+
+func (m *Metrics) ObserveRetry(ip string, op keylight.Operation, err error) {
+	m.retries.WithLabelValues(m.name(ip), string(op), string(keylight.ClassifyError(err))).Inc()
+}
+
+func (m *Metrics) name(ip string) string {
+	if name, ok := m.names[ip]; ok {
+		return name
+	}
+	return ip
+}
@@ -0,0 +1,345 @@
+// Package mqtt bridges the lights to an MQTT broker, announcing them to Home
+// Assistant through MQTT discovery.
+package mqtt
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/eckertalex/keylightctl/internal/keylight"
+	"github.com/eckertalex/keylightctl/internal/watch"
+	paho "github.com/eclipse/paho.mqtt.golang"
+)
+
+const (
+	payloadOnline  = "online"
+	payloadOffline = "offline"
+
+	qos = 1
+)
+
+type Config struct {
+	Lights []keylight.Light
+	// Prefix is the root of the bridge's state, command and availability
+	// topics.
+	Prefix string
+	// DiscoveryPrefix is the root of Home Assistant's discovery topics.
+	DiscoveryPrefix string
+	// Interval is the interval at which the state of every light is polled.
+	Interval time.Duration
+}
+
+// Bridge mirrors the state of the lights to MQTT and applies the commands it
+// receives.
+type Bridge struct {
+	config Config
+	mqtt   paho.Client
+	client keylight.Client
+
+	mu     sync.Mutex
+	states map[string]lightState
+}
+
+type lightState struct {
+	online bool
+	detail keylight.LightDetail
+}
+
+// New returns a bridge that connects to the broker with opts, which must set
+// the broker and credentials.
+func New(config Config, opts *paho.ClientOptions, client keylight.Client) *Bridge {
+	b := &Bridge{
+		config: config,
+		client: client,
+		states: make(map[string]lightState, len(config.Lights)),
+	}
+
+	opts.SetWill(b.bridgeTopic(), payloadOffline, qos, true).
+		SetAutoReconnect(true).
+		// Commands are applied concurrently, so that a slow light does not
+		// hold up the others.
+		SetOrderMatters(false).
+		SetOnConnectHandler(func(paho.Client) {
+			log.Printf("Connected to MQTT broker")
+			b.announce()
+		}).
+		SetConnectionLostHandler(func(_ paho.Client, err error) {
+			log.Printf("Lost connection to MQTT broker: %v", err)
+		})
+	b.mqtt = paho.NewClient(opts)
+	return b
+}
+
+// Run connects to the broker, then polls the lights and publishes their
+// state until ctx is done.
+func (b *Bridge) Run(ctx context.Context) error {
+	if token := b.mqtt.Connect(); token.Wait() && token.Error() != nil {
+		return token.Error()
+	}
+	defer b.mqtt.Disconnect(1000)
+
+	// Every poll is compared with the published state, which includes the
+	// results of commands, rather than with the previous poll.
+	watcher := watch.New(b.client, b.config.Lights, b.config.Interval)
+	watcher.Unchanged = true
+	watcher.Run(ctx, func(event watch.Event) {
+		if event.Type == watch.EventOffline {
+			log.Printf("%s offline: %v", event.Light.Name, event.Err)
+			b.setOffline(event.Light)
+			return
+		}
+		b.setState(event.Light, event.Current)
+	})
+
+	b.mqtt.Publish(b.bridgeTopic(), qos, true, payloadOffline).WaitTimeout(5 * time.Second)
+	return nil
+}
+
+// announce publishes the discovery config and current state of every light
+// and subscribes to the command topics.
+func (b *Bridge) announce() {
+	for _, light := range b.config.Lights {
+		config, err := json.Marshal(b.discoveryConfig(light))
+		if err != nil {
+			log.Printf("Encoding discovery config of %s: %v", light.Name, err)
+			continue
+		}
+		b.mqtt.Publish(b.discoveryTopic(light), qos, true, config)
+
+		b.mqtt.Subscribe(b.lightTopic(light, "set"), qos, func(_ paho.Client, msg paho.Message) {
+			if err := b.command(light, msg.Payload()); err != nil {
+				log.Printf("Command for %s failed: %v", light.Name, err)
+			}
+		})
+	}
+	b.mqtt.Publish(b.bridgeTopic(), qos, true, payloadOnline)
+
+	// Home Assistant announces itself when it starts, and then needs the
+	// discovery config again unless it was retained by the broker.
+	b.mqtt.Subscribe(b.config.DiscoveryPrefix+"/status", qos, func(_ paho.Client, msg paho.Message) {
+		if string(msg.Payload()) == payloadOnline {
+			go b.announce()
+		}
+	})
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for _, light := range b.config.Lights {
+		if state, ok := b.states[light.Name]; ok {
+			b.publishState(light, state)
+		}
+	}
+}
+
+// setState records the state of a light and publishes it if it changed.
+func (b *Bridge) setState(light keylight.Light, detail keylight.LightDetail) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	state := lightState{online: true, detail: detail}
+	if b.states[light.Name] == state {
+		return
+	}
+	b.states[light.Name] = state
+	b.publishState(light, state)
+}
+
+func (b *Bridge) setOffline(light keylight.Light) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	state := b.states[light.Name]
+	state.online = false
+	b.states[light.Name] = state
+	b.publishState(light, state)
+}
+
+// publishState publishes the availability of a light and, if it is online,
+// its state. b.mu must be held.
+func (b *Bridge) publishState(light keylight.Light, state lightState) {
+	if !state.online {
+		b.mqtt.Publish(b.lightTopic(light, "availability"), qos, true, payloadOffline)
+		return
+	}
+
+	payload, err := json.Marshal(toStatePayload(state.detail))
+	if err != nil {
+		log.Printf("Encoding state of %s: %v", light.Name, err)
+		return
+	}
+	b.mqtt.Publish(b.lightTopic(light, "state"), qos, true, payload)
+	b.mqtt.Publish(b.lightTopic(light, "availability"), qos, true, payloadOnline)
+}
+
+// statePayload is a light's state in Home Assistant's JSON schema.
+type statePayload struct {
+	State      string `json:"state,omitempty"`
+	ColorMode  string `json:"color_mode,omitempty"`
+	Brightness *int   `json:"brightness,omitempty"`
+	ColorTemp  *int   `json:"color_temp,omitempty"`
+}
+
+func toStatePayload(detail keylight.LightDetail) statePayload {
+	payload := statePayload{
+		State:      "OFF",
+		ColorMode:  "color_temp",
+		Brightness: &detail.Brightness,
+	}
+	if detail.On == 1 {
+		payload.State = "ON"
+	}
+	if detail.Temperature != 0 {
+		payload.ColorTemp = &detail.Temperature
+	}
+	return payload
+}
+
+// command applies a command in Home Assistant's JSON schema. Fields that are
+// left out keep their current value.
+func (b *Bridge) command(light keylight.Light, payload []byte) error {
+	var cmd statePayload
+	if err := json.Unmarshal(payload, &cmd); err != nil {
+		return fmt.Errorf("invalid command: %w", err)
+	}
+
+	detail, err := b.current(light)
+	if err != nil {
+		return err
+	}
+
+	switch strings.ToUpper(cmd.State) {
+	case "ON":
+		detail.On = 1
+	case "OFF":
+		detail.On = 0
+	case "":
+	default:
+		return fmt.Errorf("invalid state '%s'", cmd.State)
+	}
+	if cmd.Brightness != nil {
+		if err := keylight.ValidateBrightness(*cmd.Brightness); err != nil {
+			return err
+		}
+		detail.Brightness = *cmd.Brightness
+	}
+	if cmd.ColorTemp != nil {
+		detail.Temperature = min(max(*cmd.ColorTemp, keylight.MinMired), keylight.MaxMired)
+	}
+
+	status, err := b.client.UpdateLight(light.IP, detail)
+	if err != nil {
+		return err
+	}
+	if len(status.Lights) > 0 {
+		b.setState(light, status.Lights[0])
+	}
+	return nil
+}
+
+// current reads the state of a light. The last polled state is not used,
+// since it misses changes made by other apps since.
+func (b *Bridge) current(light keylight.Light) (keylight.LightDetail, error) {
+	status, err := b.client.GetLight(light.IP)
+	if err != nil {
+		return keylight.LightDetail{}, err
+	}
+	if len(status.Lights) == 0 {
+		return keylight.LightDetail{}, fmt.Errorf("no light in response")
+	}
+	return status.Lights[0], nil
+}
+
+type availability struct {
+	Topic string `json:"topic"`
+}
+
+type device struct {
+	Identifiers  []string `json:"identifiers"`
+	Name         string   `json:"name"`
+	Manufacturer string   `json:"manufacturer"`
+	Model        string   `json:"model"`
+}
+
+// discoveryConfig describes a light entity for Home Assistant's MQTT
+// discovery.
+type discoveryConfig struct {
+	Name                *string        `json:"name"`
+	UniqueID            string         `json:"unique_id"`
+	ObjectID            string         `json:"object_id"`
+	Schema              string         `json:"schema"`
+	StateTopic          string         `json:"state_topic"`
+	CommandTopic        string         `json:"command_topic"`
+	Availability        []availability `json:"availability"`
+	AvailabilityMode    string         `json:"availability_mode"`
+	Brightness          bool           `json:"brightness"`
+	BrightnessScale     int            `json:"brightness_scale"`
+	SupportedColorModes []string       `json:"supported_color_modes"`
+	MinMireds           int            `json:"min_mireds"`
+	MaxMireds           int            `json:"max_mireds"`
+	Device              device         `json:"device"`
+}
+
+func (b *Bridge) discoveryConfig(light keylight.Light) discoveryConfig {
+	id := b.objectID(light)
+	return discoveryConfig{
+		// A null name makes the entity take the name of its device.
+		Name:         nil,
+		UniqueID:     id,
+		ObjectID:     id,
+		Schema:       "json",
+		StateTopic:   b.lightTopic(light, "state"),
+		CommandTopic: b.lightTopic(light, "set"),
+		Availability: []availability{
+			{Topic: b.bridgeTopic()},
+			{Topic: b.lightTopic(light, "availability")},
+		},
+		AvailabilityMode:    "all",
+		Brightness:          true,
+		BrightnessScale:     100,
+		SupportedColorModes: []string{"color_temp"},
+		MinMireds:           keylight.MinMired,
+		MaxMireds:           keylight.MaxMired,
+		Device: device{
+			Identifiers:  []string{id},
+			Name:         light.Name,
+			Manufacturer: "Elgato",
+			Model:        "Key Light",
+		},
+	}
+}
+
+func (b *Bridge) bridgeTopic() string {
+	return b.config.Prefix + "/status"
+}
+
+func (b *Bridge) lightTopic(light keylight.Light, topic string) string {
+	return b.config.Prefix + "/" + slug(light.Name) + "/" + topic
+}
+
+func (b *Bridge) discoveryTopic(light keylight.Light) string {
+	return b.config.DiscoveryPrefix + "/light/" + b.objectID(light) + "/config"
+}
+
+func (b *Bridge) objectID(light keylight.Light) string {
+	return slug(b.config.Prefix) + "_" + slug(light.Name)
+}
+
+// slug turns a name into a topic level and object ID, keeping only lowercase
+// letters, digits and underscores.
+func slug(name string) string {
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= '0' && r <= '9':
+			return r
+		case r >= 'A' && r <= 'Z':
+			return r - 'A' + 'a'
+		default:
+			return '_'
+		}
+	}, name)
+}
@@ -0,0 +1,158 @@
+package mqtt
+
+import (
+	"context"
+	"encoding/json"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/eckertalex/keylightctl/internal/keylight"
+	"github.com/eckertalex/keylightctl/internal/keylighttest"
+	paho "github.com/eclipse/paho.mqtt.golang"
+	broker "github.com/mochi-mqtt/server/v2"
+	"github.com/mochi-mqtt/server/v2/hooks/auth"
+	"github.com/mochi-mqtt/server/v2/listeners"
+	"github.com/mochi-mqtt/server/v2/packets"
+)
+
+// startBroker runs an embedded broker and returns its address.
+func startBroker(t *testing.T) (*broker.Server, string) {
+	t.Helper()
+
+	server := broker.New(&broker.Options{InlineClient: true})
+	if err := server.AddHook(new(auth.AllowHook), nil); err != nil {
+		t.Fatal(err)
+	}
+	tcp := listeners.NewTCP(listeners.Config{ID: "tcp", Address: "127.0.0.1:0"})
+	if err := server.AddListener(tcp); err != nil {
+		t.Fatal(err)
+	}
+	if err := server.Serve(); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { server.Close() })
+	return server, tcp.Address()
+}
+
+// waitForState waits for a state message matching want.
+func waitForState(t *testing.T, states <-chan statePayload, want func(statePayload) bool) statePayload {
+	t.Helper()
+
+	timeout := time.After(5 * time.Second)
+	for {
+		select {
+		case state := <-states:
+			if want(state) {
+				return state
+			}
+		case <-timeout:
+			t.Fatal("timed out waiting for state")
+		}
+	}
+}
+
+func TestBridge(t *testing.T) {
+	server, addr := startBroker(t)
+
+	states := make(chan statePayload, 100)
+	err := server.Subscribe("keylightctl/left/state", 1, func(_ *broker.Client, _ packets.Subscription, pk packets.Packet) {
+		var state statePayload
+		if err := json.Unmarshal(pk.Payload, &state); err != nil {
+			t.Errorf("invalid state payload %q: %v", pk.Payload, err)
+			return
+		}
+		states <- state
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	const ip = "10.0.0.1"
+	light := keylighttest.NewLights(map[string]keylight.LightDetail{ip: {On: 0, Brightness: 20, Temperature: 200}})
+	// revert, if set, is the state another app changes the light to right
+	// after the next update.
+	var revert atomic.Pointer[keylight.LightDetail]
+	light.AfterUpdate = func(ip string, _ keylight.LightDetail) {
+		if detail := revert.Swap(nil); detail != nil {
+			light.Set(ip, *detail)
+		}
+	}
+	bridge := New(Config{
+		Lights:          []keylight.Light{{Name: "Left", IP: ip}},
+		Prefix:          "keylightctl",
+		DiscoveryPrefix: "homeassistant",
+		Interval:        20 * time.Millisecond,
+	}, paho.NewClientOptions().AddBroker("tcp://"+addr).SetClientID("bridge"), light)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() { done <- bridge.Run(ctx) }()
+	defer func() {
+		cancel()
+		if err := <-done; err != nil {
+			t.Errorf("Run: %v", err)
+		}
+	}()
+
+	t.Run("state is published", func(t *testing.T) {
+		state := waitForState(t, states, func(s statePayload) bool { return s.State == "OFF" })
+		if *state.Brightness != 20 || *state.ColorTemp != 200 {
+			t.Errorf("got brightness %d, color_temp %d, want 20, 200", *state.Brightness, *state.ColorTemp)
+		}
+
+		light.Set(ip, keylight.LightDetail{On: 1, Brightness: 35, Temperature: 250})
+		waitForState(t, states, func(s statePayload) bool {
+			return s.State == "ON" && *s.Brightness == 35 && *s.ColorTemp == 250
+		})
+	})
+
+	t.Run("commands are applied", func(t *testing.T) {
+		// Wait for the bridge to subscribe to its command topic.
+		time.Sleep(100 * time.Millisecond)
+
+		if err := server.Publish("keylightctl/left/set", []byte(`{"state":"OFF","color_temp":400}`), false, 1); err != nil {
+			t.Fatal(err)
+		}
+		waitForState(t, states, func(s statePayload) bool { return s.State == "OFF" })
+
+		want := keylight.LightDetail{On: 0, Brightness: 35, Temperature: keylight.MaxMired}
+		if got := light.Get(ip); got != want {
+			t.Errorf("light is %+v, want %+v", got, want)
+		}
+
+		if err := server.Publish("keylightctl/left/set", []byte(`{"state":"ON","brightness":70}`), false, 1); err != nil {
+			t.Fatal(err)
+		}
+		waitForState(t, states, func(s statePayload) bool { return s.State == "ON" && *s.Brightness == 70 })
+	})
+
+	t.Run("changes back are published", func(t *testing.T) {
+		// Let the bridge poll the light in its current state.
+		time.Sleep(100 * time.Millisecond)
+
+		// Another app changes the light back before the next poll.
+		before := light.Get(ip)
+		revert.Store(&before)
+		if err := server.Publish("keylightctl/left/set", []byte(`{"brightness":55}`), false, 1); err != nil {
+			t.Fatal(err)
+		}
+		waitForState(t, states, func(s statePayload) bool { return *s.Brightness == 55 })
+		state := waitForState(t, states, func(s statePayload) bool { return *s.Brightness != 55 })
+		if got := toStatePayload(before); *state.Brightness != *got.Brightness || state.State != got.State {
+			t.Errorf("published %+v after the change back, want %+v", state, got)
+		}
+
+		// Commands build on the state the light is in.
+		if err := server.Publish("keylightctl/left/set", []byte(`{"color_temp":300}`), false, 1); err != nil {
+			t.Fatal(err)
+		}
+		waitForState(t, states, func(s statePayload) bool { return *s.ColorTemp == 300 })
+
+		want := before
+		want.Temperature = 300
+		if got := light.Get(ip); got != want {
+			t.Errorf("light is %+v, want %+v", got, want)
+		}
+	})
+}
@@ -0,0 +1,202 @@
+// Package obs applies scenes in response to events of OBS Studio, received
+// through obs-websocket.
+package obs
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"slices"
+	"time"
+
+	"github.com/eckertalex/keylightctl/internal/scene"
+)
+
+const DefaultURL = "ws://localhost:4455"
+
+// Event is an OBS event a trigger reacts to.
+type Event string
+
+const (
+	EventStreamStarted    Event = "stream-started"
+	EventStreamStopped    Event = "stream-stopped"
+	EventRecordingStarted Event = "recording-started"
+	EventRecordingStopped Event = "recording-stopped"
+	// EventSceneSwitched fires when OBS switches its program to the scene
+	// named by the trigger.
+	EventSceneSwitched Event = "scene-switched"
+)
+
+var Events = []Event{EventStreamStarted, EventStreamStopped, EventRecordingStarted, EventRecordingStopped, EventSceneSwitched}
+
+// Trigger applies a scene when an OBS event occurs.
+type Trigger struct {
+	Event    Event         `mapstructure:"event"`
+	OBSScene string        `mapstructure:"obs_scene"`
+	Scene    string        `mapstructure:"scene"`
+	Fade     time.Duration `mapstructure:"fade"`
+}
+
+func (t Trigger) String() string {
+	if t.Event == EventSceneSwitched {
+		return fmt.Sprintf("%s to '%s'", t.Event, t.OBSScene)
+	}
+	return string(t.Event)
+}
+
+// Config is the obs block of the config file.
+type Config struct {
+	URL      string    `mapstructure:"url"`
+	Password string    `mapstructure:"password"`
+	Triggers []Trigger `mapstructure:"triggers"`
+}
+
+// Validate checks that every trigger reacts to a known event and applies an
+// existing scene. A missing URL defaults to DefaultURL.
+func Validate(c *Config, scenes []scene.Scene) error {
+	if c.URL == "" {
+		c.URL = DefaultURL
+	}
+	for i, t := range c.Triggers {
+		if err := validateTrigger(t, scenes); err != nil {
+			return fmt.Errorf("trigger %d: %w", i+1, err)
+		}
+	}
+	return nil
+}
+
+func validateTrigger(t Trigger, scenes []scene.Scene) error {
+	if !slices.Contains(Events, t.Event) {
+		return fmt.Errorf("unknown event '%s', expected one of %v", t.Event, Events)
+	}
+	if (t.Event == EventSceneSwitched) != (t.OBSScene != "") {
+		return fmt.Errorf("obs_scene must be set exactly for the %s event", EventSceneSwitched)
+	}
+	if scene.Find(scenes, t.Scene) == nil {
+		return fmt.Errorf("scene '%s' not found", t.Scene)
+	}
+	if t.Fade < 0 {
+		return fmt.Errorf("fade must not be negative")
+	}
+	return nil
+}
+
+// Runner listens to OBS and applies the scenes of the matching triggers. It
+// reconnects when the connection fails.
+type Runner struct {
+	URL      string
+	Password string
+	Triggers []Trigger
+	// Apply applies the scene of a trigger.
+	Apply func(ctx context.Context, t Trigger) error
+	// MaxBackoff caps the delay between reconnection attempts, which doubles
+	// after every failed attempt.
+	MaxBackoff time.Duration
+}
+
+func NewRunner(config Config, apply func(ctx context.Context, t Trigger) error) *Runner {
+	return &Runner{
+		URL:        config.URL,
+		Password:   config.Password,
+		Triggers:   config.Triggers,
+		Apply:      apply,
+		MaxBackoff: 30 * time.Second,
+	}
+}
+
+// Run listens to OBS until ctx is done. It only returns an error if OBS
+// rejects the password, since retrying cannot fix that.
+func (r *Runner) Run(ctx context.Context) error {
+	delay := time.Second
+	for {
+		connected, err := r.session(ctx)
+		if ctx.Err() != nil {
+			return nil
+		}
+		if errors.Is(err, errAuthenticationFailed) {
+			return err
+		}
+		if connected {
+			delay = time.Second
+		}
+		log.Printf("Connection to OBS failed: %v, retrying in %s", err, delay)
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-time.After(delay):
+		}
+		delay = min(delay*2, r.MaxBackoff)
+	}
+}
+
+// session connects to OBS and applies triggers until the connection fails.
+// It reports whether it got connected.
+func (r *Runner) session(ctx context.Context) (bool, error) {
+	conn, version, err := connect(ctx, r.URL, r.Password)
+	if err != nil {
+		return false, err
+	}
+	defer conn.Close()
+	log.Printf("Connected to obs-websocket %s at %s", version, r.URL)
+
+	// Unblock the read below when ctx is done.
+	stop := context.AfterFunc(ctx, func() { conn.Close() })
+	defer stop()
+
+	for {
+		e, err := readEvent(conn)
+		if err != nil {
+			return true, err
+		}
+
+		event, obsScene, ok := decodeEvent(e)
+		if !ok {
+			continue
+		}
+		for _, t := range r.Triggers {
+			if t.Event != event || t.OBSScene != obsScene {
+				continue
+			}
+			if err := r.Apply(ctx, t); err != nil {
+				log.Printf("Trigger %s failed: %v", t, err)
+				continue
+			}
+			log.Printf("Trigger %s applied scene '%s'", t, t.Scene)
+		}
+	}
+}
+
+// decodeEvent maps an obs-websocket event to the event of a trigger, along
+// with the OBS scene switched to. Events no trigger can react to are
+// reported as not ok.
+func decodeEvent(e event) (Event, string, bool) {
+	switch e.EventType {
+	case "StreamStateChanged", "RecordStateChanged":
+		var data outputStateChanged
+		if err := json.Unmarshal(e.EventData, &data); err != nil {
+			return "", "", false
+		}
+
+		streaming := e.EventType == "StreamStateChanged"
+		switch {
+		case data.OutputState == outputStarted && streaming:
+			return EventStreamStarted, "", true
+		case data.OutputState == outputStopped && streaming:
+			return EventStreamStopped, "", true
+		case data.OutputState == outputStarted:
+			return EventRecordingStarted, "", true
+		case data.OutputState == outputStopped:
+			return EventRecordingStopped, "", true
+		}
+	case "CurrentProgramSceneChanged":
+		var data programSceneChanged
+		if err := json.Unmarshal(e.EventData, &data); err != nil {
+			return "", "", false
+		}
+		return EventSceneSwitched, data.SceneName, true
+	}
+	return "", "", false
+}
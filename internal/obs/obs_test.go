@@ -0,0 +1,168 @@
+package obs
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+const (
+	testSalt      = "lM1GncleQOaCu9lT1yeUZhFYnqhsLLP1G5lAGo3ixaI="
+	testChallenge = "+IxH4CnCiqpX1rM9scsNynZzbOe4KhDeYcTNS3PDaeY="
+)
+
+// fakeOBS serves obs-websocket v5 on a test server. It requires the
+// password, if set, and sends the events once the client is identified.
+func fakeOBS(t *testing.T, password string, events ...message) string {
+	t.Helper()
+
+	upgrader := websocket.Upgrader{Subprotocols: []string{subprotocol}}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			t.Errorf("upgrade: %v", err)
+			return
+		}
+		defer conn.Close()
+
+		if conn.Subprotocol() != subprotocol {
+			t.Errorf("got subprotocol %q, want %q", conn.Subprotocol(), subprotocol)
+		}
+
+		h := map[string]any{"obsWebSocketVersion": "5.5.0", "rpcVersion": rpcVersion}
+		if password != "" {
+			h["authentication"] = map[string]string{"challenge": testChallenge, "salt": testSalt}
+		}
+		if err := writeMessage(conn, opHello, h); err != nil {
+			t.Errorf("write hello: %v", err)
+			return
+		}
+
+		var id identify
+		if err := readMessage(conn, opIdentify, &id); err != nil {
+			t.Errorf("read identify: %v", err)
+			return
+		}
+		if id.EventSubscriptions != subscribeScenes|subscribeOutputs {
+			t.Errorf("got event subscriptions %d, want %d", id.EventSubscriptions, subscribeScenes|subscribeOutputs)
+		}
+		if password != "" && id.Authentication != authenticate(password, testSalt, testChallenge) {
+			msg := websocket.FormatCloseMessage(closeAuthenticationFailed, "Authentication failed.")
+			conn.WriteControl(websocket.CloseMessage, msg, time.Now().Add(time.Second))
+			return
+		}
+
+		if err := writeMessage(conn, opIdentified, map[string]int{"negotiatedRpcVersion": rpcVersion}); err != nil {
+			t.Errorf("write identified: %v", err)
+			return
+		}
+		for _, e := range events {
+			if err := conn.WriteJSON(e); err != nil {
+				return
+			}
+		}
+
+		// Hold the connection until the client closes it.
+		for {
+			if _, _, err := conn.ReadMessage(); err != nil {
+				return
+			}
+		}
+	}))
+	t.Cleanup(srv.Close)
+	return "ws" + strings.TrimPrefix(srv.URL, "http")
+}
+
+func obsEvent(eventType string, data any) message {
+	return message{Op: opEvent, D: mustMarshal(event{EventType: eventType, EventData: mustMarshal(data)})}
+}
+
+func mustMarshal(v any) json.RawMessage {
+	d, err := json.Marshal(v)
+	if err != nil {
+		panic(err)
+	}
+	return d
+}
+
+func TestRunnerAppliesTriggers(t *testing.T) {
+	url := fakeOBS(t, "secret",
+		obsEvent("StreamStateChanged", outputStateChanged{OutputState: outputStarted}),
+		// Responses to requests are skipped.
+		message{Op: 7, D: mustMarshal(map[string]string{"requestType": "GetVersion"})},
+		obsEvent("InputMuteStateChanged", map[string]any{"inputName": "Mic", "inputMuted": true}),
+		obsEvent("CurrentProgramSceneChanged", programSceneChanged{SceneName: "Camera"}),
+		obsEvent("CurrentProgramSceneChanged", programSceneChanged{SceneName: "Other"}),
+		obsEvent("RecordStateChanged", outputStateChanged{OutputState: outputStopped}),
+	)
+
+	applied := make(chan string, 10)
+	runner := NewRunner(Config{
+		URL:      url,
+		Password: "secret",
+		Triggers: []Trigger{
+			{Event: EventStreamStarted, Scene: "live"},
+			{Event: EventSceneSwitched, OBSScene: "Camera", Scene: "camera"},
+			{Event: EventSceneSwitched, OBSScene: "Desktop", Scene: "desktop"},
+			{Event: EventRecordingStopped, Scene: "off"},
+		},
+	}, func(_ context.Context, t Trigger) error {
+		applied <- t.Scene
+		return nil
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() { done <- runner.Run(ctx) }()
+
+	for _, want := range []string{"live", "camera", "off"} {
+		select {
+		case got := <-applied:
+			if got != want {
+				t.Errorf("applied scene '%s', want '%s'", got, want)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatalf("timed out waiting for scene '%s'", want)
+		}
+	}
+
+	cancel()
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Errorf("Run: %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Run did not return after cancellation")
+	}
+}
+
+func TestRunnerRejectedPassword(t *testing.T) {
+	url := fakeOBS(t, "secret")
+
+	runner := NewRunner(Config{URL: url, Password: "wrong"}, func(context.Context, Trigger) error {
+		t.Error("no trigger should be applied")
+		return nil
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := runner.Run(ctx); !errors.Is(err, errAuthenticationFailed) {
+		t.Errorf("got %v, want %v", err, errAuthenticationFailed)
+	}
+}
+
+func TestAuthenticate(t *testing.T) {
+	// Example from the obs-websocket protocol documentation.
+	got := authenticate("supersecretpassword", testSalt, testChallenge)
+	if want := "1Ct943GAT+6YQUUX47Ia/ncufilbe6+oD6lY+5kaCu4="; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
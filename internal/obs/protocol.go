@@ -0,0 +1,166 @@
+package obs
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// The parts of the obs-websocket v5 protocol the runner needs. See
+// https://github.com/obsproject/obs-websocket/blob/master/docs/generated/protocol.md
+const (
+	subprotocol = "obswebsocket.json"
+	rpcVersion  = 1
+
+	opHello      = 0
+	opIdentify   = 1
+	opIdentified = 2
+	opEvent      = 5
+
+	subscribeScenes  = 1 << 2
+	subscribeOutputs = 1 << 6
+
+	closeAuthenticationFailed = 4009
+
+	outputStarted = "OBS_WEBSOCKET_OUTPUT_STARTED"
+	outputStopped = "OBS_WEBSOCKET_OUTPUT_STOPPED"
+)
+
+var errAuthenticationFailed = errors.New("authentication failed")
+
+type message struct {
+	Op int             `json:"op"`
+	D  json.RawMessage `json:"d"`
+}
+
+type hello struct {
+	OBSWebSocketVersion string `json:"obsWebSocketVersion"`
+	Authentication      *struct {
+		Challenge string `json:"challenge"`
+		Salt      string `json:"salt"`
+	} `json:"authentication"`
+}
+
+type identify struct {
+	RPCVersion         int    `json:"rpcVersion"`
+	Authentication     string `json:"authentication,omitempty"`
+	EventSubscriptions int    `json:"eventSubscriptions"`
+}
+
+type event struct {
+	EventType string          `json:"eventType"`
+	EventData json.RawMessage `json:"eventData"`
+}
+
+type outputStateChanged struct {
+	OutputState string `json:"outputState"`
+}
+
+type programSceneChanged struct {
+	SceneName string `json:"sceneName"`
+}
+
+// connect opens a connection to obs-websocket and identifies with the
+// password, if OBS requires one, subscribing to scene and output events. It
+// returns the version of obs-websocket.
+func connect(ctx context.Context, url, password string) (*websocket.Conn, string, error) {
+	dialer := websocket.Dialer{
+		Subprotocols:     []string{subprotocol},
+		HandshakeTimeout: 10 * time.Second,
+	}
+	conn, _, err := dialer.DialContext(ctx, url, nil)
+	if err != nil {
+		return nil, "", err
+	}
+
+	version, err := handshake(conn, password)
+	if err != nil {
+		conn.Close()
+		return nil, "", err
+	}
+	return conn, version, nil
+}
+
+func handshake(conn *websocket.Conn, password string) (string, error) {
+	var h hello
+	if err := readMessage(conn, opHello, &h); err != nil {
+		return "", err
+	}
+
+	id := identify{RPCVersion: rpcVersion, EventSubscriptions: subscribeScenes | subscribeOutputs}
+	if h.Authentication != nil {
+		if password == "" {
+			return "", fmt.Errorf("OBS requires a password")
+		}
+		id.Authentication = authenticate(password, h.Authentication.Salt, h.Authentication.Challenge)
+	}
+	if err := writeMessage(conn, opIdentify, id); err != nil {
+		return "", err
+	}
+
+	if err := readMessage(conn, opIdentified, nil); err != nil {
+		return "", err
+	}
+	return h.OBSWebSocketVersion, nil
+}
+
+// authenticate computes the authentication string from the password and the
+// salt and challenge sent by OBS.
+func authenticate(password, salt, challenge string) string {
+	secret := sha256.Sum256([]byte(password + salt))
+	auth := sha256.Sum256([]byte(base64.StdEncoding.EncodeToString(secret[:]) + challenge))
+	return base64.StdEncoding.EncodeToString(auth[:])
+}
+
+// readMessage reads the next message, which must have the given op code, and
+// decodes its data into v unless v is nil.
+func readMessage(conn *websocket.Conn, op int, v any) error {
+	var msg message
+	if err := conn.ReadJSON(&msg); err != nil {
+		if websocket.IsCloseError(err, closeAuthenticationFailed) {
+			return errAuthenticationFailed
+		}
+		return err
+	}
+	if msg.Op != op {
+		return fmt.Errorf("unexpected message with op code %d, expected %d", msg.Op, op)
+	}
+	if v == nil {
+		return nil
+	}
+	return json.Unmarshal(msg.D, v)
+}
+
+func writeMessage(conn *websocket.Conn, op int, v any) error {
+	d, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	return conn.WriteJSON(message{Op: op, D: d})
+}
+
+// readEvent reads messages until the next event. Other messages are
+// skipped.
+func readEvent(conn *websocket.Conn) (event, error) {
+	for {
+		var msg message
+		if err := conn.ReadJSON(&msg); err != nil {
+			return event{}, err
+		}
+		if msg.Op != opEvent {
+			continue
+		}
+
+		var e event
+		if err := json.Unmarshal(msg.D, &e); err != nil {
+			return event{}, err
+		}
+		return e, nil
+	}
+}
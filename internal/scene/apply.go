@@ -0,0 +1,116 @@
+package scene
+
+import (
+	"context"
+	"errors"
+	"math"
+	"time"
+
+	"github.com/eckertalex/keylightctl/internal/keylight"
+)
+
+// fadeStep is the interval between intermediate updates during a fade.
+const fadeStep = 100 * time.Millisecond
+
+// minFadeBrightness is where a fade starts from, or ends at, when the light
+// is switched on or off.
+const minFadeBrightness = 1
+
+// ApplyState moves the light at ip to state. With a positive fade duration
+// brightness and temperature are interpolated from the current values.
+func ApplyState(ctx context.Context, client keylight.Client, ip string, state State, fade time.Duration) (*keylight.LightStatus, error) {
+	if fade <= 0 && state.On != nil {
+		return client.UpdateLight(ip, state.Detail(keylight.LightDetail{}))
+	}
+
+	current, err := getDetail(client, ip)
+	if err != nil {
+		return nil, err
+	}
+
+	target := state.Detail(current)
+	if fade <= 0 || target == current {
+		return client.UpdateLight(ip, target)
+	}
+	return fadeTo(ctx, client, ip, current, target, fade)
+}
+
+// Detail returns the device settings for the state, using current for any
+// field the state leaves unchanged. The temperature is converted to the
+// nearest mired value, so that it changes gradually.
+func (s State) Detail(current keylight.LightDetail) keylight.LightDetail {
+	detail := current
+	if s.On != nil {
+		detail.On = 0
+		if *s.On {
+			detail.On = 1
+		}
+	}
+	if s.Brightness != nil {
+		detail.Brightness = *s.Brightness
+	}
+	if s.Temperature != nil {
+		detail.Temperature = keylight.KelvinToMiredExact(*s.Temperature)
+	}
+	return detail
+}
+
+func getDetail(client keylight.Client, ip string) (keylight.LightDetail, error) {
+	status, err := client.GetLight(ip)
+	if err != nil {
+		return keylight.LightDetail{}, err
+	}
+	if len(status.Lights) == 0 {
+		return keylight.LightDetail{}, errors.New("empty status")
+	}
+	return status.Lights[0], nil
+}
+
+// fadeTo interpolates from one state to another. Lights being switched off
+// fade down to the minimum and are then switched off with their target
+// brightness, so that they come back at that brightness next time.
+func fadeTo(ctx context.Context, client keylight.Client, ip string, from, to keylight.LightDetail, fade time.Duration) (*keylight.LightStatus, error) {
+	startBrightness, endBrightness := from.Brightness, to.Brightness
+	if from.On == 0 {
+		startBrightness = minFadeBrightness
+	}
+	if to.On == 0 {
+		endBrightness = minFadeBrightness
+	}
+	if from.On == 0 && to.On == 0 {
+		return client.UpdateLight(ip, to)
+	}
+
+	steps := max(int(fade/fadeStep), 1)
+	ticker := time.NewTicker(fade / time.Duration(steps))
+	defer ticker.Stop()
+
+	for i := 1; i < steps; i++ {
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-ticker.C:
+		}
+
+		progress := float64(i) / float64(steps)
+		step := keylight.LightDetail{
+			On:          1,
+			Brightness:  lerp(startBrightness, endBrightness, progress),
+			Temperature: lerp(from.Temperature, to.Temperature, progress),
+		}
+		if _, err := client.UpdateLight(ip, step); err != nil {
+			return nil, err
+		}
+	}
+
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case <-ticker.C:
+	}
+	return client.UpdateLight(ip, to)
+}
+
+func lerp(from, to int, progress float64) int {
+	return from + int(math.Round(float64(to-from)*progress))
+}
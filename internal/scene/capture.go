@@ -0,0 +1,74 @@
+package scene
+
+import (
+	"fmt"
+
+	"github.com/eckertalex/keylightctl/internal/keylight"
+)
+
+// StateFromDetail returns the complete state described by a device status.
+// The temperature is kept exact, so that applying the state reproduces it.
+func StateFromDetail(detail keylight.LightDetail) State {
+	on := detail.On == 1
+	brightness := detail.Brightness
+	state := State{On: &on, Brightness: &brightness}
+	if detail.Temperature != 0 {
+		temperature := keylight.MiredToKelvinExact(detail.Temperature)
+		state.Temperature = &temperature
+	}
+	return state
+}
+
+// Capture builds a scene that restores every given light to its current
+// state.
+func Capture(name string, lights []keylight.Light, details []keylight.LightDetail) Scene {
+	s := Scene{Name: name}
+	for i, light := range lights {
+		s.Lights = append(s.Lights, LightState{
+			Light: light.Name,
+			State: StateFromDetail(details[i]),
+		})
+	}
+	return s
+}
+
+// Diff describes, per light, how applying next instead of prev would change
+// the result. Lights whose resolved state is identical are omitted.
+func Diff(prev, next Scene, lights []keylight.LightConfig, groups []keylight.GroupConfig) ([]string, error) {
+	prevTargets, err := Resolve(prev, lights, groups)
+	if err != nil {
+		return nil, err
+	}
+	nextTargets, err := Resolve(next, lights, groups)
+	if err != nil {
+		return nil, err
+	}
+
+	before := targetStrings(prevTargets)
+	after := targetStrings(nextTargets)
+
+	var changes []string
+	for _, light := range lights {
+		b, inPrev := before[light.Name]
+		a, inNext := after[light.Name]
+		switch {
+		case inPrev && inNext && a == b:
+			continue
+		case inPrev && inNext:
+			changes = append(changes, fmt.Sprintf("~ %s: %s -> %s", light.Name, b, a))
+		case inPrev:
+			changes = append(changes, fmt.Sprintf("- %s: %s", light.Name, b))
+		case inNext:
+			changes = append(changes, fmt.Sprintf("+ %s: %s", light.Name, a))
+		}
+	}
+	return changes, nil
+}
+
+func targetStrings(targets []Target) map[string]string {
+	out := make(map[string]string, len(targets))
+	for _, target := range targets {
+		out[target.Light.Name] = target.State.String()
+	}
+	return out
+}
@@ -0,0 +1,36 @@
+package scene
+
+import (
+	"context"
+	"testing"
+
+	"github.com/eckertalex/keylightctl/internal/keylight"
+	"github.com/eckertalex/keylightctl/internal/keylighttest"
+)
+
+func TestCaptureApplyRoundTrip(t *testing.T) {
+	const ip = "10.0.0.1"
+	lights := []keylight.LightConfig{{Light: keylight.Light{Name: "Left", IP: ip}}}
+
+	for mired := keylight.MinMired; mired <= keylight.MaxMired; mired++ {
+		for _, on := range []int{0, 1} {
+			captured := keylight.LightDetail{On: on, Brightness: 37, Temperature: mired}
+			s := Capture("saved", []keylight.Light{lights[0].Light}, []keylight.LightDetail{captured})
+			if err := ValidateEntry(s.Lights[0], lights, nil); err != nil {
+				t.Fatalf("captured %+v as an invalid state: %v", captured, err)
+			}
+
+			targets, err := Resolve(s, lights, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			fake := keylighttest.NewLights(map[string]keylight.LightDetail{ip: {On: 1 - on, Brightness: 80, Temperature: 200}})
+			if _, err := ApplyState(context.Background(), fake, ip, targets[0].State, 0); err != nil {
+				t.Fatal(err)
+			}
+			if got := fake.Get(ip); got != captured {
+				t.Errorf("captured %+v, applied %+v", captured, got)
+			}
+		}
+	}
+}
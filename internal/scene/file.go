@@ -0,0 +1,165 @@
+package scene
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/pelletier/go-toml/v2"
+)
+
+var (
+	scenesHeader   = regexp.MustCompile(`^\s*\[\[\s*scenes\s*\]\]\s*(#.*)?$`)
+	sceneSubHeader = regexp.MustCompile(`^\s*\[{1,2}\s*scenes\s*\.`)
+	tableHeader    = regexp.MustCompile(`^\s*\[`)
+)
+
+// Encode returns the scene as a [[scenes]] TOML block.
+func Encode(s Scene) string {
+	var b strings.Builder
+	b.WriteString("[[scenes]]\n")
+	fmt.Fprintf(&b, "name = %s\n", quote(s.Name))
+	if s.Description != "" {
+		fmt.Fprintf(&b, "description = %s\n", quote(s.Description))
+	}
+	if s.TurnOffOthers {
+		b.WriteString("turn_off_others = true\n")
+	}
+
+	for _, entry := range s.Lights {
+		b.WriteString("\n[[scenes.lights]]\n")
+		if entry.Group != "" {
+			fmt.Fprintf(&b, "group = %s\n", quote(entry.Group))
+		} else {
+			fmt.Fprintf(&b, "light = %s\n", quote(entry.Light))
+		}
+		if entry.On != nil {
+			fmt.Fprintf(&b, "on = %t\n", *entry.On)
+		}
+		if entry.Brightness != nil {
+			fmt.Fprintf(&b, "brightness = %d\n", *entry.Brightness)
+		}
+		if entry.Temperature != nil {
+			fmt.Fprintf(&b, "temperature = %d\n", *entry.Temperature)
+		}
+	}
+	return b.String()
+}
+
+func quote(s string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`, "\t", `\t`).Replace(s) + `"`
+}
+
+// Save writes the scene into the TOML config file at path. An existing scene
+// with the same name is replaced in place; otherwise the scene is appended.
+// All other content of the file is preserved. If path is a symlink, the file
+// it points to is written, so that the link is kept.
+func Save(path string, s Scene) error {
+	path, err := filepath.EvalSymlinks(path)
+	if err != nil {
+		return err
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		return err
+	}
+
+	content, err := replaceScene(string(data), s)
+	if err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.WriteString(content); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Chmod(info.Mode().Perm()); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+func replaceScene(content string, s Scene) (string, error) {
+	lines := strings.SplitAfter(content, "\n")
+	block := strings.Split(strings.TrimRight(Encode(s), "\n"), "\n")
+
+	start, end := -1, -1
+	for i := 0; i < len(lines); i++ {
+		if !scenesHeader.MatchString(lines[i]) {
+			continue
+		}
+
+		j := i + 1
+		for j < len(lines) && (!tableHeader.MatchString(lines[j]) || sceneSubHeader.MatchString(lines[j])) {
+			j++
+		}
+		// Leave trailing blank lines and comments to whatever follows.
+		for j > i+1 && isBlankOrComment(lines[j-1]) {
+			j--
+		}
+
+		name, err := sceneName(strings.Join(lines[i:j], ""))
+		if err != nil {
+			return "", err
+		}
+		if name == s.Name {
+			start, end = i, j
+			break
+		}
+		i = j - 1
+	}
+
+	if start == -1 {
+		out := strings.TrimRight(content, "\n")
+		if out != "" {
+			out += "\n\n"
+		}
+		return out + strings.Join(block, "\n") + "\n", nil
+	}
+
+	var out []string
+	out = append(out, lines[:start]...)
+	for _, line := range block {
+		out = append(out, line+"\n")
+	}
+	out = append(out, lines[end:]...)
+	return strings.Join(out, ""), nil
+}
+
+func isBlankOrComment(line string) bool {
+	trimmed := strings.TrimSpace(line)
+	return trimmed == "" || strings.HasPrefix(trimmed, "#")
+}
+
+func sceneName(block string) (string, error) {
+	var parsed struct {
+		Scenes []struct {
+			Name string `toml:"name"`
+		} `toml:"scenes"`
+	}
+	if err := toml.Unmarshal([]byte(block), &parsed); err != nil {
+		return "", fmt.Errorf("parsing existing scene: %w", err)
+	}
+	if len(parsed.Scenes) == 0 {
+		return "", nil
+	}
+	return parsed.Scenes[0].Name, nil
+}
@@ -0,0 +1,56 @@
+package scene
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestSaveKeepsSymlink(t *testing.T) {
+	dir := t.TempDir()
+	dotfiles := filepath.Join(dir, "dotfiles")
+	if err := os.Mkdir(dotfiles, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	target := filepath.Join(dotfiles, "keylightctl.toml")
+	if err := os.WriteFile(target, []byte("# my lights\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	link := filepath.Join(dir, "keylightctl.toml")
+	if err := os.Symlink(filepath.Join("dotfiles", "keylightctl.toml"), link); err != nil {
+		t.Fatal(err)
+	}
+
+	brightness := 40
+	if err := Save(link, Scene{Name: "focus", Lights: []LightState{{Light: "Left", State: State{Brightness: &brightness}}}}); err != nil {
+		t.Fatal(err)
+	}
+
+	info, err := os.Lstat(link)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Mode()&os.ModeSymlink == 0 {
+		t.Errorf("config file is no longer a symlink")
+	}
+
+	data, err := os.ReadFile(target)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(string(data), "# my lights\n") || !strings.Contains(string(data), `name = "focus"`) {
+		t.Errorf("linked file is:\n%s", data)
+	}
+	if info, err := os.Stat(target); err != nil || info.Mode().Perm() != 0o600 {
+		t.Errorf("linked file mode is %v (%v), want 0600", info.Mode().Perm(), err)
+	}
+
+	entries, err := os.ReadDir(dotfiles)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 {
+		t.Errorf("temporary files left behind: %v", entries)
+	}
+}
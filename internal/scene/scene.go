@@ -0,0 +1,168 @@
+package scene
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/eckertalex/keylightctl/internal/keylight"
+)
+
+// State is the desired state of a light. Nil fields are left unchanged.
+// Temperature is in Kelvin.
+type State struct {
+	On          *bool `mapstructure:"on"`
+	Brightness  *int  `mapstructure:"brightness"`
+	Temperature *int  `mapstructure:"temperature"`
+}
+
+// LightState applies a State to a single light or to every light of a group.
+type LightState struct {
+	Light string `mapstructure:"light"`
+	Group string `mapstructure:"group"`
+	State `mapstructure:",squash"`
+}
+
+type Scene struct {
+	Name          string       `mapstructure:"name"`
+	Description   string       `mapstructure:"description"`
+	TurnOffOthers bool         `mapstructure:"turn_off_others"`
+	Lights        []LightState `mapstructure:"lights"`
+}
+
+// Target is the state a scene resolves to for one light.
+type Target struct {
+	Light keylight.Light
+	State State
+}
+
+func Find(scenes []Scene, name string) *Scene {
+	for i := range scenes {
+		if scenes[i].Name == name {
+			return &scenes[i]
+		}
+	}
+	return nil
+}
+
+// Merge returns s with the fields set in other taking precedence.
+func (s State) Merge(other State) State {
+	if other.On != nil {
+		s.On = other.On
+	}
+	if other.Brightness != nil {
+		s.Brightness = other.Brightness
+	}
+	if other.Temperature != nil {
+		s.Temperature = other.Temperature
+	}
+	return s
+}
+
+func (s State) String() string {
+	var parts []string
+	if s.On != nil {
+		if *s.On {
+			parts = append(parts, "ON")
+		} else {
+			parts = append(parts, "OFF")
+		}
+	}
+	if s.Brightness != nil {
+		parts = append(parts, fmt.Sprintf("%d%%", *s.Brightness))
+	}
+	if s.Temperature != nil {
+		parts = append(parts, fmt.Sprintf("%dK", *s.Temperature))
+	}
+	if len(parts) == 0 {
+		return "unchanged"
+	}
+	return strings.Join(parts, ", ")
+}
+
+// Resolve expands the scene's light and group entries into one target per
+// affected light, in configuration order. Later entries override earlier ones
+// field by field. Lights not mentioned are only included, switched off, when
+// the scene turns off the others.
+func Resolve(s Scene, lights []keylight.LightConfig, groups []keylight.GroupConfig) ([]Target, error) {
+	states := make(map[string]State)
+	for _, entry := range s.Lights {
+		names := []string{entry.Light}
+		if entry.Group != "" {
+			var err error
+			if names, err = keylight.ExpandGroup(groups, entry.Group); err != nil {
+				return nil, fmt.Errorf("scene '%s': %w", s.Name, err)
+			}
+		}
+		for _, name := range names {
+			states[name] = states[name].Merge(entry.State)
+		}
+	}
+
+	off := false
+	var targets []Target
+	for _, light := range lights {
+		state, ok := states[light.Name]
+		if !ok {
+			if !s.TurnOffOthers {
+				continue
+			}
+			state = State{On: &off}
+		}
+		targets = append(targets, Target{Light: light.Light, State: state})
+	}
+	return targets, nil
+}
+
+// Validate checks that scene names are unique and that every entry refers to
+// exactly one configured light or group with valid values.
+func Validate(scenes []Scene, lights []keylight.LightConfig, groups []keylight.GroupConfig) error {
+	seen := make(map[string]bool, len(scenes))
+	for _, s := range scenes {
+		if s.Name == "" {
+			return fmt.Errorf("scene without a name")
+		}
+		if seen[s.Name] {
+			return fmt.Errorf("duplicate scene '%s'", s.Name)
+		}
+		seen[s.Name] = true
+
+		for _, entry := range s.Lights {
+			if err := ValidateEntry(entry, lights, groups); err != nil {
+				return fmt.Errorf("scene '%s': %w", s.Name, err)
+			}
+		}
+	}
+	return nil
+}
+
+// ValidateEntry checks that an entry refers to exactly one configured light
+// or group and only sets valid values.
+func ValidateEntry(entry LightState, lights []keylight.LightConfig, groups []keylight.GroupConfig) error {
+	switch {
+	case entry.Light != "" && entry.Group != "":
+		return fmt.Errorf("entry sets both light '%s' and group '%s'", entry.Light, entry.Group)
+	case entry.Light != "":
+		if !slices.ContainsFunc(lights, func(l keylight.LightConfig) bool { return l.Name == entry.Light }) {
+			return fmt.Errorf("light '%s' not found", entry.Light)
+		}
+	case entry.Group != "":
+		if keylight.FindGroup(groups, entry.Group) == nil {
+			return fmt.Errorf("group '%s' not found", entry.Group)
+		}
+	default:
+		return fmt.Errorf("entry without a light or group")
+	}
+
+	if entry.Brightness != nil {
+		if err := keylight.ValidateBrightness(*entry.Brightness); err != nil {
+			return err
+		}
+	}
+	if entry.Temperature != nil {
+		if err := keylight.ValidateTemperature(*entry.Temperature); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,89 @@
+package schedule
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+var weekdays = map[string]int{
+	"sun": 0, "sunday": 0,
+	"mon": 1, "monday": 1,
+	"tue": 2, "tuesday": 2,
+	"wed": 3, "wednesday": 3,
+	"thu": 4, "thursday": 4,
+	"fri": 5, "friday": 5,
+	"sat": 6, "saturday": 6,
+}
+
+// ParseAt converts an "HH:MM on <days>" expression to a cron expression.
+// Days are "weekdays", "weekends", "every day" or a comma-separated list of
+// day names and ranges such as "mon-wed,fri". Without "on <days>", the
+// expression fires every day.
+func ParseAt(at string) (string, error) {
+	clock, days, hasDays := strings.Cut(strings.ToLower(strings.TrimSpace(at)), " on ")
+
+	hour, minute, err := parseClock(strings.TrimSpace(clock))
+	if err != nil {
+		return "", fmt.Errorf("invalid time '%s': %w", at, err)
+	}
+
+	dow := "*"
+	if hasDays {
+		if dow, err = parseDays(strings.TrimSpace(days)); err != nil {
+			return "", fmt.Errorf("invalid days in '%s': %w", at, err)
+		}
+	}
+	return fmt.Sprintf("%d %d * * %s", minute, hour, dow), nil
+}
+
+func parseClock(clock string) (int, int, error) {
+	h, m, ok := strings.Cut(clock, ":")
+	if !ok {
+		return 0, 0, fmt.Errorf("expected HH:MM")
+	}
+	hour, err := strconv.Atoi(h)
+	if err != nil || hour < 0 || hour > 23 {
+		return 0, 0, fmt.Errorf("hour must be between 0 and 23")
+	}
+	minute, err := strconv.Atoi(m)
+	if err != nil || len(m) != 2 || minute < 0 || minute > 59 {
+		return 0, 0, fmt.Errorf("minute must be between 00 and 59")
+	}
+	return hour, minute, nil
+}
+
+func parseDays(days string) (string, error) {
+	switch days {
+	case "weekdays":
+		return "1-5", nil
+	case "weekends":
+		return "0,6", nil
+	case "every day", "everyday", "daily":
+		return "*", nil
+	}
+
+	var fields []string
+	for _, part := range strings.Split(days, ",") {
+		part = strings.TrimSpace(part)
+		if from, to, isRange := strings.Cut(part, "-"); isRange {
+			start, ok1 := weekdays[strings.TrimSpace(from)]
+			end, ok2 := weekdays[strings.TrimSpace(to)]
+			if !ok1 || !ok2 {
+				return "", fmt.Errorf("unknown day range '%s'", part)
+			}
+			if start > end {
+				return "", fmt.Errorf("day range '%s' must not wrap around the week", part)
+			}
+			fields = append(fields, fmt.Sprintf("%d-%d", start, end))
+			continue
+		}
+
+		day, ok := weekdays[part]
+		if !ok {
+			return "", fmt.Errorf("unknown day '%s'", part)
+		}
+		fields = append(fields, strconv.Itoa(day))
+	}
+	return strings.Join(fields, ","), nil
+}
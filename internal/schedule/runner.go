@@ -0,0 +1,131 @@
+package schedule
+
+import (
+	"context"
+	"log"
+	"sort"
+	"time"
+
+	"github.com/eckertalex/keylightctl/internal/clock"
+)
+
+const (
+	// maxSleep bounds how long the runner sleeps at once, so that it notices
+	// jumps of the wall clock, for example after a suspend, in time.
+	maxSleep = time.Minute
+	// lateness is how late a run may start before it counts as missed.
+	lateness = time.Minute
+)
+
+// Runner performs the runs of a set of schedules as they come due.
+type Runner struct {
+	Schedules []Schedule
+	Clock     clock.Clock
+	// Apply performs a run of a schedule. now is the time of the clock when
+	// the run is performed.
+	Apply func(ctx context.Context, s Schedule, now time.Time) error
+}
+
+func NewRunner(schedules []Schedule, apply func(ctx context.Context, s Schedule, now time.Time) error) *Runner {
+	return &Runner{
+		Schedules: schedules,
+		Clock:     clock.System,
+		Apply:     apply,
+	}
+}
+
+// Run performs due runs until ctx is done. Runs that are missed because the
+// runner could not keep up, or the machine was suspended, are handled
+// according to the catch-up policy of their schedule. Runs that were due
+// before Run was called are not caught up.
+func (r *Runner) Run(ctx context.Context) {
+	if len(r.Schedules) == 0 {
+		return
+	}
+
+	// Strip the monotonic clock reading, which does not advance while the
+	// machine is suspended.
+	last := r.Clock.Now().Round(0)
+	for {
+		wait := maxSleep
+		if next, ok := r.next(last); ok {
+			wait = min(max(next.Sub(last), 0), maxSleep)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-r.Clock.After(wait):
+		}
+
+		now := r.Clock.Now().Round(0)
+		for _, run := range r.due(last, now) {
+			if ctx.Err() != nil {
+				return
+			}
+			if err := r.Apply(ctx, run.Schedule, now); err != nil {
+				log.Printf("Schedule '%s' failed: %v", run.Schedule.Name, err)
+				continue
+			}
+			log.Printf("Schedule '%s' ran: %s", run.Schedule.Name, run.Schedule.Action())
+		}
+		last = now
+	}
+}
+
+// next returns the first time after t a run of any schedule starts at.
+func (r *Runner) next(t time.Time) (time.Time, bool) {
+	var first time.Time
+	for _, s := range r.Schedules {
+		next, err := s.start(t)
+		if err != nil || next.IsZero() {
+			continue
+		}
+		if first.IsZero() || next.Before(first) {
+			first = next
+		}
+	}
+	return first, !first.IsZero()
+}
+
+// due returns the runs to perform for the period (from, now], in order.
+// Missed runs are dropped or reduced to the latest one, depending on the
+// catch-up policy of their schedule.
+func (r *Runner) due(from, now time.Time) []Run {
+	var runs []Run
+	for _, s := range r.Schedules {
+		var (
+			onTime       []Run
+			missed       int
+			firstMissed  time.Time
+			latestMissed time.Time
+		)
+		for at, err := s.start(from); err == nil && !at.IsZero() && !at.After(now); at, err = s.start(at) {
+			late := now.Sub(at)
+			switch {
+			case late <= lateness:
+				onTime = append(onTime, Run{Schedule: s, At: at})
+			case s.CatchUp == CatchUpLatest && (s.CatchUpWithin == 0 || late <= s.CatchUpWithin):
+				latestMissed = at
+			default:
+				if missed == 0 {
+					firstMissed = at
+				}
+				missed++
+			}
+		}
+
+		if missed > 0 {
+			log.Printf("Schedule '%s' missed %d run(s) since %s", s.Name, missed, firstMissed.Format(time.DateTime))
+		}
+		// Catching up is pointless if the schedule runs on time anyway.
+		if !latestMissed.IsZero() && len(onTime) == 0 {
+			log.Printf("Schedule '%s' catching up on its run at %s", s.Name, latestMissed.Format(time.DateTime))
+			runs = append(runs, Run{Schedule: s, At: latestMissed})
+		}
+		runs = append(runs, onTime...)
+	}
+
+	sort.SliceStable(runs, func(i, j int) bool { return runs[i].At.Before(runs[j].At) })
+	return runs
+}
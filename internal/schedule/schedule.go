@@ -0,0 +1,244 @@
+// Package schedule applies states and scenes to the lights at configured
+// times.
+package schedule
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+	"time"
+	// Embed the time zone database, which is not available on every system.
+	_ "time/tzdata"
+
+	"github.com/eckertalex/keylightctl/internal/keylight"
+	"github.com/eckertalex/keylightctl/internal/scene"
+	"github.com/robfig/cron/v3"
+)
+
+// CatchUpPolicy decides what happens to runs that were missed, for example
+// because the machine was suspended.
+type CatchUpPolicy string
+
+const (
+	// CatchUpSkip drops missed runs.
+	CatchUpSkip CatchUpPolicy = "skip"
+	// CatchUpLatest performs the latest missed run of a schedule once.
+	CatchUpLatest CatchUpPolicy = "latest"
+)
+
+var CatchUpPolicies = []CatchUpPolicy{CatchUpSkip, CatchUpLatest}
+
+// Schedule applies a scene, or a state to a light or group, at the times
+// given by either a cron expression or an "HH:MM on <days>" expression.
+type Schedule struct {
+	Name string `mapstructure:"name"`
+	Cron string `mapstructure:"cron"`
+	At   string `mapstructure:"at"`
+	// Timezone is the IANA time zone the schedule is evaluated in. The local
+	// time zone is used if it is empty.
+	Timezone string `mapstructure:"timezone"`
+
+	Scene            string `mapstructure:"scene"`
+	scene.LightState `mapstructure:",squash"`
+	Fade             time.Duration `mapstructure:"fade"`
+	// Sunrise ramps the lights up from off over this duration, ending at the
+	// time the schedule fires at, instead of applying the state at once.
+	Sunrise time.Duration `mapstructure:"sunrise"`
+
+	CatchUp CatchUpPolicy `mapstructure:"catch_up"`
+	// CatchUpWithin limits catching up to runs missed by at most this long.
+	// Zero means no limit.
+	CatchUpWithin time.Duration `mapstructure:"catch_up_within"`
+}
+
+// When returns the expression the schedule fires on.
+func (s Schedule) When() string {
+	when := s.Cron
+	if s.At != "" {
+		when = s.At
+	}
+	if s.Timezone != "" {
+		when += " (" + s.Timezone + ")"
+	}
+	return when
+}
+
+// Action describes what the schedule does.
+func (s Schedule) Action() string {
+	var action string
+	switch {
+	case s.Scene != "":
+		action = "scene " + s.Scene
+	case s.Light != "":
+		action = fmt.Sprintf("light %s: %s", s.Light, s.State)
+	default:
+		action = fmt.Sprintf("group %s: %s", s.Group, s.State)
+	}
+	if s.Fade > 0 {
+		action += fmt.Sprintf(" (fade %s)", s.Fade)
+	}
+	if s.Sunrise > 0 {
+		action += fmt.Sprintf(" (sunrise %s)", s.Sunrise)
+	}
+	return action
+}
+
+// Targets resolves the state every affected light is moved to.
+func (s Schedule) Targets(scenes []scene.Scene, lights []keylight.LightConfig, groups []keylight.GroupConfig) ([]scene.Target, error) {
+	if s.Scene != "" {
+		sc := scene.Find(scenes, s.Scene)
+		if sc == nil {
+			return nil, fmt.Errorf("scene '%s' not found", s.Scene)
+		}
+		return scene.Resolve(*sc, lights, groups)
+	}
+	return scene.Resolve(scene.Scene{Name: s.Name, Lights: []scene.LightState{s.LightState}}, lights, groups)
+}
+
+// Next returns the first time after t the schedule fires at.
+func (s Schedule) Next(t time.Time) (time.Time, error) {
+	spec, loc, err := s.parse()
+	if err != nil {
+		return time.Time{}, err
+	}
+	return spec.Next(t.In(loc)), nil
+}
+
+// start returns the first time after t a run of the schedule starts at. Runs
+// of a sunrise schedule start ahead of the time the schedule fires at.
+func (s Schedule) start(t time.Time) (time.Time, error) {
+	next, err := s.Next(t.Add(s.Sunrise))
+	if err != nil || next.IsZero() {
+		return next, err
+	}
+	return next.Add(-s.Sunrise), nil
+}
+
+// SunriseEnd returns the time the sunrise of a run started before now ends
+// at. A run that was caught up on long after it was due ends right away.
+func (s Schedule) SunriseEnd(now time.Time) (time.Time, error) {
+	end, err := s.Next(now.Add(-s.Sunrise))
+	if err != nil {
+		return time.Time{}, err
+	}
+	if end.IsZero() || end.Sub(now) > s.Sunrise {
+		return now, nil
+	}
+	return end, nil
+}
+
+func (s Schedule) parse() (cron.Schedule, *time.Location, error) {
+	loc := time.Local
+	if s.Timezone != "" {
+		var err error
+		if loc, err = time.LoadLocation(s.Timezone); err != nil {
+			return nil, nil, fmt.Errorf("invalid timezone: %w", err)
+		}
+	}
+
+	expr := s.Cron
+	if s.At != "" {
+		var err error
+		if expr, err = ParseAt(s.At); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	spec, err := cron.ParseStandard(expr)
+	if err != nil {
+		return nil, nil, fmt.Errorf("invalid cron expression: %w", err)
+	}
+	return spec, loc, nil
+}
+
+// Run is a single time a schedule fires at.
+type Run struct {
+	Schedule Schedule
+	At       time.Time
+}
+
+// Upcoming returns the next n runs of all schedules after t, in order.
+func Upcoming(schedules []Schedule, t time.Time, n int) ([]Run, error) {
+	var runs []Run
+	for _, s := range schedules {
+		next := t
+		for range n {
+			var err error
+			if next, err = s.Next(next); err != nil {
+				return nil, fmt.Errorf("schedule '%s': %w", s.Name, err)
+			}
+			if next.IsZero() {
+				break
+			}
+			runs = append(runs, Run{Schedule: s, At: next})
+		}
+	}
+
+	sort.SliceStable(runs, func(i, j int) bool { return runs[i].At.Before(runs[j].At) })
+	return runs[:min(n, len(runs))], nil
+}
+
+// Validate checks that schedule names are unique, that every schedule fires
+// on a valid expression in a known time zone, and that it applies either an
+// existing scene or a valid state. A missing catch-up policy defaults to
+// skip.
+func Validate(schedules []Schedule, scenes []scene.Scene, lights []keylight.LightConfig, groups []keylight.GroupConfig) error {
+	seen := make(map[string]bool, len(schedules))
+	for i := range schedules {
+		s := &schedules[i]
+		if s.Name == "" {
+			return fmt.Errorf("schedule without a name")
+		}
+		if seen[s.Name] {
+			return fmt.Errorf("duplicate schedule '%s'", s.Name)
+		}
+		seen[s.Name] = true
+
+		if err := validate(s, scenes, lights, groups); err != nil {
+			return fmt.Errorf("schedule '%s': %w", s.Name, err)
+		}
+	}
+	return nil
+}
+
+func validate(s *Schedule, scenes []scene.Scene, lights []keylight.LightConfig, groups []keylight.GroupConfig) error {
+	if (s.Cron == "") == (s.At == "") {
+		return fmt.Errorf("exactly one of cron and at must be set")
+	}
+	if _, _, err := s.parse(); err != nil {
+		return err
+	}
+
+	if s.Scene != "" {
+		if s.Light != "" || s.Group != "" || s.State != (scene.State{}) {
+			return fmt.Errorf("a schedule applying a scene cannot set a light, group or state")
+		}
+		if scene.Find(scenes, s.Scene) == nil {
+			return fmt.Errorf("scene '%s' not found", s.Scene)
+		}
+	} else {
+		if err := scene.ValidateEntry(s.LightState, lights, groups); err != nil {
+			return err
+		}
+		if s.State == (scene.State{}) {
+			return fmt.Errorf("no state to apply")
+		}
+	}
+
+	if s.Fade < 0 {
+		return fmt.Errorf("fade must not be negative")
+	}
+	if s.Sunrise < 0 {
+		return fmt.Errorf("sunrise must not be negative")
+	}
+	if s.Sunrise > 0 && s.Fade > 0 {
+		return fmt.Errorf("a sunrise schedule cannot fade")
+	}
+	if s.CatchUp == "" {
+		s.CatchUp = CatchUpSkip
+	}
+	if !slices.Contains(CatchUpPolicies, s.CatchUp) {
+		return fmt.Errorf("unknown catch-up policy '%s', expected one of %v", s.CatchUp, CatchUpPolicies)
+	}
+	return nil
+}
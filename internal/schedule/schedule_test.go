@@ -0,0 +1,115 @@
+package schedule
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/eckertalex/keylightctl/internal/keylighttest"
+)
+
+var date = keylighttest.Date
+
+func sunriseSchedule() Schedule {
+	return Schedule{Name: "wake", At: "07:00", Timezone: "UTC", Sunrise: 30 * time.Minute, CatchUp: CatchUpLatest}
+}
+
+func TestStart(t *testing.T) {
+	s := sunriseSchedule()
+	tests := []struct {
+		name string
+		t    time.Time
+		want time.Time
+	}{
+		{"before the sunrise", date(10, 6, 0), date(10, 6, 30)},
+		{"during the sunrise", date(10, 6, 45), date(11, 6, 30)},
+		{"after the alarm", date(10, 8, 0), date(11, 6, 30)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := s.start(tt.t)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !got.Equal(tt.want) {
+				t.Errorf("start(%s) = %s, want %s", tt.t, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSunriseCatchUp(t *testing.T) {
+	s := sunriseSchedule()
+	tests := []struct {
+		name  string
+		now   time.Time
+		end   time.Time
+		runAt time.Time
+	}{
+		// The run is on time and ramps up until the alarm.
+		{"on time", date(10, 6, 30), date(10, 7, 0), date(10, 6, 30)},
+		// A run caught up on during the sunrise ramps up from where it is.
+		{"during the sunrise", date(10, 6, 40), date(10, 7, 0), date(10, 6, 30)},
+		// A run caught up on after the alarm ends right away.
+		{"after the alarm", date(10, 9, 0), date(10, 9, 0), date(10, 6, 30)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := &Runner{Schedules: []Schedule{s}}
+			runs := r.due(date(10, 6, 0), tt.now)
+			if len(runs) != 1 {
+				t.Fatalf("got %d runs, want 1", len(runs))
+			}
+			if !runs[0].At.Equal(tt.runAt) {
+				t.Errorf("run at %s, want %s", runs[0].At, tt.runAt)
+			}
+
+			end, err := s.SunriseEnd(tt.now)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !end.Equal(tt.end) {
+				t.Errorf("SunriseEnd(%s) = %s, want %s", tt.now, end, tt.end)
+			}
+		})
+	}
+
+	t.Run("skipped", func(t *testing.T) {
+		s := sunriseSchedule()
+		s.CatchUp = CatchUpSkip
+		r := &Runner{Schedules: []Schedule{s}}
+		if runs := r.due(date(10, 6, 0), date(10, 6, 40)); len(runs) != 0 {
+			t.Errorf("got %d runs, want the missed run skipped", len(runs))
+		}
+	})
+}
+
+func TestRunnerPassesClockTime(t *testing.T) {
+	clock := keylighttest.NewClock(date(10, 6, 0))
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var got []time.Time
+	r := &Runner{
+		Schedules: []Schedule{sunriseSchedule()},
+		Clock:     clock,
+		Apply: func(_ context.Context, s Schedule, now time.Time) error {
+			got = append(got, now)
+			if len(got) == 2 {
+				cancel()
+			}
+			return nil
+		},
+	}
+	r.Run(ctx)
+
+	want := []time.Time{date(10, 6, 30), date(11, 6, 30)}
+	if len(got) != len(want) {
+		t.Fatalf("got runs at %v, want %v", got, want)
+	}
+	for i := range want {
+		if !got[i].Equal(want[i]) {
+			t.Errorf("run %d at %s, want %s", i+1, got[i], want[i])
+		}
+	}
+}
@@ -0,0 +1,293 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"crypto/subtle"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"slices"
+	"strings"
+
+	"github.com/eckertalex/keylightctl/api"
+	"github.com/eckertalex/keylightctl/internal/keylight"
+	"github.com/eckertalex/keylightctl/internal/scene"
+)
+
+// Scope decides what a token may do.
+type Scope string
+
+const (
+	// ScopeRead allows reading the state of the lights and subscribing to
+	// events.
+	ScopeRead Scope = "read"
+	// ScopeReadWrite additionally allows changing the lights.
+	ScopeReadWrite Scope = "read-write"
+)
+
+var Scopes = []Scope{ScopeRead, ScopeReadWrite}
+
+// TokenConfig is a bearer token accepted by the daemon. A token without
+// lights or groups may access every light.
+type TokenConfig struct {
+	Name   string   `mapstructure:"name"`
+	Token  string   `mapstructure:"token"`
+	Scope  Scope    `mapstructure:"scope"`
+	Lights []string `mapstructure:"lights"`
+	Groups []string `mapstructure:"groups"`
+}
+
+// ValidateTokens checks that every token has a unique name and secret, a
+// known scope, and only references configured lights and groups. A missing
+// scope defaults to read.
+func ValidateTokens(tokens []TokenConfig, lights []keylight.LightConfig, groups []keylight.GroupConfig) error {
+	names := make(map[string]bool, len(tokens))
+	secrets := make(map[string]bool, len(tokens))
+	for i := range tokens {
+		token := &tokens[i]
+		if token.Name == "" {
+			return fmt.Errorf("token without a name")
+		}
+		if names[token.Name] {
+			return fmt.Errorf("duplicate token '%s'", token.Name)
+		}
+		names[token.Name] = true
+
+		if token.Token == "" {
+			return fmt.Errorf("token '%s': empty token", token.Name)
+		}
+		if secrets[token.Token] {
+			return fmt.Errorf("token '%s': token is already used by another entry", token.Name)
+		}
+		secrets[token.Token] = true
+
+		if token.Scope == "" {
+			token.Scope = ScopeRead
+		}
+		if !slices.Contains(Scopes, token.Scope) {
+			return fmt.Errorf("token '%s': unknown scope '%s', expected one of %v", token.Name, token.Scope, Scopes)
+		}
+
+		for _, light := range token.Lights {
+			if !slices.ContainsFunc(lights, func(l keylight.LightConfig) bool { return l.Name == light }) {
+				return fmt.Errorf("token '%s': light '%s' not found", token.Name, light)
+			}
+		}
+		for _, group := range token.Groups {
+			if keylight.FindGroup(groups, group) == nil {
+				return fmt.Errorf("token '%s': group '%s' not found", token.Name, group)
+			}
+		}
+	}
+	return nil
+}
+
+// principal is the identity a request is made with.
+type principal struct {
+	name  string
+	scope Scope
+	// lights is the set of lights the principal may access, or nil for all
+	// lights.
+	lights map[string]bool
+}
+
+// unrestricted is used for requests over the Unix socket, and for every
+// request when no tokens are configured.
+func unrestricted(name string) *principal {
+	return &principal{name: name, scope: ScopeReadWrite}
+}
+
+func newPrincipal(token TokenConfig, groups []keylight.GroupConfig) *principal {
+	p := &principal{name: token.Name, scope: token.Scope}
+	if len(token.Lights) == 0 && len(token.Groups) == 0 {
+		return p
+	}
+
+	p.lights = make(map[string]bool)
+	for _, light := range token.Lights {
+		p.lights[light] = true
+	}
+	for _, group := range token.Groups {
+		// Groups are validated on startup.
+		members, _ := keylight.ExpandGroup(groups, group)
+		for _, light := range members {
+			p.lights[light] = true
+		}
+	}
+	return p
+}
+
+func (p *principal) canWrite() bool {
+	return p.scope == ScopeReadWrite
+}
+
+func (p *principal) canAccess(light string) bool {
+	return p.lights == nil || p.lights[light]
+}
+
+func (p *principal) canAccessAll(lights []string) bool {
+	for _, light := range lights {
+		if !p.canAccess(light) {
+			return false
+		}
+	}
+	return true
+}
+
+// canAccessScene reports whether the principal may access every light the
+// scene touches.
+func (s *Server) canAccessScene(p *principal, sc scene.Scene) bool {
+	targets, err := scene.Resolve(sc, s.config.Lights, s.config.Groups)
+	if err != nil {
+		return false
+	}
+	for _, target := range targets {
+		if !p.canAccess(target.Light.Name) {
+			return false
+		}
+	}
+	return true
+}
+
+// canSeeEvent reports whether an event concerns only lights the principal
+// may access.
+func (s *Server) canSeeEvent(p *principal, event api.Event) bool {
+	if event.Scene != "" {
+		sc := scene.Find(s.config.Scenes, event.Scene)
+		return sc != nil && s.canAccessScene(p, *sc)
+	}
+	return p.canAccess(event.Light)
+}
+
+func forbidden(w http.ResponseWriter, p *principal, light string) {
+	writeError(w, http.StatusForbidden, fmt.Errorf("token '%s' may not access light '%s'", p.name, light))
+}
+
+type localConnKey struct{}
+
+type principalKey struct{}
+
+// ConnContext marks requests arriving over a Unix socket as local. Local
+// requests are trusted, since access to the socket is restricted by file
+// permissions. Use it as http.Server.ConnContext.
+func ConnContext(ctx context.Context, conn net.Conn) context.Context {
+	if _, ok := conn.(*net.UnixConn); ok {
+		return context.WithValue(ctx, localConnKey{}, true)
+	}
+	return ctx
+}
+
+func isLocal(r *http.Request) bool {
+	local, _ := r.Context().Value(localConnKey{}).(bool)
+	return local
+}
+
+func principalFrom(r *http.Request) *principal {
+	if p, ok := r.Context().Value(principalKey{}).(*principal); ok {
+		return p
+	}
+	return unrestricted("anonymous")
+}
+
+// identify returns the principal of a request, or false if the request needs
+// a token and did not carry a valid one.
+func (s *Server) identify(r *http.Request) (*principal, bool) {
+	if isLocal(r) {
+		return unrestricted("local"), true
+	}
+	if len(s.tokens) == 0 {
+		return unrestricted("anonymous"), true
+	}
+
+	secret, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if !ok {
+		return nil, false
+	}
+	// Compare against every token, so that the time taken does not reveal
+	// which one matched.
+	var match *principal
+	for _, token := range s.tokens {
+		if subtle.ConstantTimeCompare([]byte(secret), []byte(token.secret)) == 1 {
+			match = token.principal
+		}
+	}
+	return match, match != nil
+}
+
+// authenticate identifies the principal of every request, enforces its
+// scope, and writes an audit log entry for every request that changes a
+// light.
+func (s *Server) authenticate(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mutating := r.Method != http.MethodGet && r.Method != http.MethodHead
+		var body []byte
+		if mutating {
+			body = readBody(r)
+		}
+
+		p, ok := s.identify(r)
+		if !ok {
+			if mutating {
+				s.audit(r, "-", body, http.StatusUnauthorized)
+			}
+			w.Header().Set("WWW-Authenticate", `Bearer realm="keylightctl"`)
+			writeError(w, http.StatusUnauthorized, fmt.Errorf("missing or invalid token"))
+			return
+		}
+		r = r.WithContext(context.WithValue(r.Context(), principalKey{}, p))
+
+		if !mutating {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		rec := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+		if p.canWrite() {
+			next.ServeHTTP(rec, r)
+		} else {
+			writeError(rec, http.StatusForbidden, fmt.Errorf("token '%s' is read-only", p.name))
+		}
+		s.audit(r, p.name, body, rec.status)
+	})
+}
+
+func (s *Server) audit(r *http.Request, principal string, body []byte, status int) {
+	if s.config.AuditLog == nil {
+		return
+	}
+
+	remote := r.RemoteAddr
+	if isLocal(r) || remote == "" {
+		remote = "unix"
+	}
+	request := r.Method + " " + r.URL.Path
+	if body = bytes.TrimSpace(body); len(body) > 0 {
+		request += " " + string(body)
+	}
+	s.config.AuditLog.Printf("audit: token=%s remote=%s %s -> %d", principal, remote, request, status)
+}
+
+// maxAuditBody bounds the part of a request body that is kept for the audit
+// log.
+const maxAuditBody = 64 << 10
+
+// readBody reads the request body and replaces it, so that handlers can
+// still read it.
+func readBody(r *http.Request) []byte {
+	body, _ := io.ReadAll(io.LimitReader(r.Body, maxAuditBody))
+	r.Body = io.NopCloser(io.MultiReader(bytes.NewReader(body), r.Body))
+	return body
+}
+
+// statusRecorder remembers the status code written to a response.
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+func (r *statusRecorder) WriteHeader(status int) {
+	r.status = status
+	r.ResponseWriter.WriteHeader(status)
+}
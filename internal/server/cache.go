@@ -0,0 +1,61 @@
+package server
+
+import (
+	"sync"
+	"time"
+
+	"github.com/eckertalex/keylightctl/internal/keylight"
+)
+
+type cacheEntry struct {
+	online    bool
+	known     bool
+	detail    keylight.LightDetail
+	err       error
+	updatedAt time.Time
+}
+
+// cache holds the last known state of every light, keyed by name.
+type cache struct {
+	mu      sync.RWMutex
+	entries map[string]cacheEntry
+}
+
+func newCache() *cache {
+	return &cache{entries: make(map[string]cacheEntry)}
+}
+
+func (c *cache) get(name string) cacheEntry {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.entries[name]
+}
+
+// setState records the state of an online light and returns the previous
+// entry.
+func (c *cache) setState(name string, detail keylight.LightDetail) cacheEntry {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	prev := c.entries[name]
+	c.entries[name] = cacheEntry{
+		online:    true,
+		known:     true,
+		detail:    detail,
+		updatedAt: time.Now(),
+	}
+	return prev
+}
+
+// setError marks a light as offline and returns the previous entry.
+func (c *cache) setError(name string, err error) cacheEntry {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	prev := c.entries[name]
+	entry := prev
+	entry.online = false
+	entry.known = true
+	entry.err = err
+	entry.updatedAt = time.Now()
+	c.entries[name] = entry
+	return prev
+}
@@ -0,0 +1,67 @@
+package server
+
+import (
+	"github.com/eckertalex/keylightctl/api"
+	"github.com/eckertalex/keylightctl/internal/keylight"
+	"github.com/eckertalex/keylightctl/internal/scene"
+)
+
+func apiState(detail keylight.LightDetail) *api.State {
+	state := &api.State{On: detail.On == 1, Brightness: detail.Brightness}
+	if detail.Temperature != 0 {
+		state.Temperature = keylight.MiredToKelvin(detail.Temperature)
+		state.Mired = detail.Temperature
+	}
+	return state
+}
+
+func (s *Server) apiLight(light keylight.LightConfig) api.Light {
+	entry := s.cache.get(light.Name)
+	out := api.Light{
+		Name:      light.Name,
+		IP:        light.IP,
+		Tags:      light.Tags,
+		Online:    entry.online,
+		UpdatedAt: entry.updatedAt,
+	}
+	if entry.online {
+		out.State = apiState(entry.detail)
+	} else if entry.err != nil {
+		out.Error = entry.err.Error()
+	}
+	return out
+}
+
+// apiLights returns the cached state of each light, with errs overriding the
+// error of the lights whose last request failed.
+func (s *Server) apiLights(lights []keylight.LightConfig, errs map[string]error) []api.Light {
+	out := make([]api.Light, len(lights))
+	for i, light := range lights {
+		out[i] = s.apiLight(light)
+		if err, ok := errs[light.Name]; ok {
+			out[i].Error = err.Error()
+		}
+	}
+	return out
+}
+
+func apiScene(s scene.Scene) api.Scene {
+	out := api.Scene{
+		Name:          s.Name,
+		Description:   s.Description,
+		TurnOffOthers: s.TurnOffOthers,
+		Lights:        make([]api.SceneLight, len(s.Lights)),
+	}
+	for i, entry := range s.Lights {
+		out.Lights[i] = api.SceneLight{
+			Light: entry.Light,
+			Group: entry.Group,
+			StatePatch: api.StatePatch{
+				On:          entry.On,
+				Brightness:  entry.Brightness,
+				Temperature: entry.Temperature,
+			},
+		}
+	}
+	return out
+}
@@ -0,0 +1,93 @@
+package server
+
+import (
+	"fmt"
+	"slices"
+	"sync"
+
+	"github.com/eckertalex/keylightctl/api"
+)
+
+// SlowSubscriberPolicy decides what happens when a subscriber's buffer is
+// full.
+type SlowSubscriberPolicy string
+
+const (
+	// DisconnectSlowSubscribers closes the stream of a subscriber that does
+	// not keep up.
+	DisconnectSlowSubscribers SlowSubscriberPolicy = "disconnect"
+	// DropEventsForSlowSubscribers discards events that do not fit into a
+	// subscriber's buffer and keeps the stream open.
+	DropEventsForSlowSubscribers SlowSubscriberPolicy = "drop"
+)
+
+var SlowSubscriberPolicies = []SlowSubscriberPolicy{DisconnectSlowSubscribers, DropEventsForSlowSubscribers}
+
+func ParseSlowSubscriberPolicy(policy string) (SlowSubscriberPolicy, error) {
+	if slices.Contains(SlowSubscriberPolicies, SlowSubscriberPolicy(policy)) {
+		return SlowSubscriberPolicy(policy), nil
+	}
+	return "", fmt.Errorf("unknown slow subscriber policy '%s', expected one of %v", policy, SlowSubscriberPolicies)
+}
+
+type subscriber struct {
+	events chan api.Event
+	// done is closed when the subscriber is disconnected by the hub.
+	done chan struct{}
+}
+
+// hub fans out events to every subscriber through a buffered channel each.
+type hub struct {
+	mu     sync.Mutex
+	subs   map[*subscriber]struct{}
+	buffer int
+	policy SlowSubscriberPolicy
+}
+
+func newHub(buffer int, policy SlowSubscriberPolicy) *hub {
+	return &hub{
+		subs:   make(map[*subscriber]struct{}),
+		buffer: buffer,
+		policy: policy,
+	}
+}
+
+func (h *hub) subscribe() *subscriber {
+	sub := &subscriber{
+		events: make(chan api.Event, h.buffer),
+		done:   make(chan struct{}),
+	}
+
+	h.mu.Lock()
+	h.subs[sub] = struct{}{}
+	h.mu.Unlock()
+	return sub
+}
+
+func (h *hub) unsubscribe(sub *subscriber) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.remove(sub)
+}
+
+func (h *hub) remove(sub *subscriber) {
+	if _, ok := h.subs[sub]; ok {
+		delete(h.subs, sub)
+		close(sub.done)
+	}
+}
+
+func (h *hub) publish(event api.Event) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	for sub := range h.subs {
+		select {
+		case sub.events <- event:
+		default:
+			if h.policy == DisconnectSlowSubscribers {
+				h.remove(sub)
+			}
+		}
+	}
+}
@@ -0,0 +1,275 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"time"
+
+	"github.com/eckertalex/keylightctl/api"
+	"github.com/eckertalex/keylightctl/internal/keylight"
+	"github.com/eckertalex/keylightctl/internal/scene"
+)
+
+func (s *Server) routes() {
+	s.mux.HandleFunc("GET /api/v1/lights", s.handleListLights)
+	s.mux.HandleFunc("GET /api/v1/lights/{name}", s.handleGetLight)
+	s.mux.HandleFunc("PATCH /api/v1/lights/{name}", s.handlePatchLight)
+	s.mux.HandleFunc("GET /api/v1/groups", s.handleListGroups)
+	s.mux.HandleFunc("POST /api/v1/groups/{name}/toggle", s.handleToggleGroup)
+	s.mux.HandleFunc("GET /api/v1/scenes", s.handleListScenes)
+	s.mux.HandleFunc("POST /api/v1/scenes/{name}/apply", s.handleApplyScene)
+	s.mux.HandleFunc("GET /api/v1/events", s.handleEvents)
+	s.mux.HandleFunc("GET /api/v1/events/ws", s.handleEventsWebSocket)
+	if s.config.Metrics != nil {
+		s.mux.HandleFunc("GET /metrics", s.handleMetrics)
+	}
+}
+
+func (s *Server) handleListLights(w http.ResponseWriter, r *http.Request) {
+	p := principalFrom(r)
+	lights := make([]api.Light, 0, len(s.config.Lights))
+	for _, light := range s.config.Lights {
+		if p.canAccess(light.Name) {
+			lights = append(lights, s.apiLight(light))
+		}
+	}
+	writeJSON(w, http.StatusOK, lights)
+}
+
+func (s *Server) handleGetLight(w http.ResponseWriter, r *http.Request) {
+	light := s.findLight(r.PathValue("name"))
+	if light == nil {
+		writeError(w, http.StatusNotFound, fmt.Errorf("light '%s' not found", r.PathValue("name")))
+		return
+	}
+	if p := principalFrom(r); !p.canAccess(light.Name) {
+		forbidden(w, p, light.Name)
+		return
+	}
+	if r.URL.Query().Get("refresh") == "1" {
+		// Errors are recorded, so that the light is reported offline.
+		s.refresh(light.Light)
+	}
+	writeJSON(w, http.StatusOK, s.apiLight(*light))
+}
+
+func (s *Server) handlePatchLight(w http.ResponseWriter, r *http.Request) {
+	light := s.findLight(r.PathValue("name"))
+	if light == nil {
+		writeError(w, http.StatusNotFound, fmt.Errorf("light '%s' not found", r.PathValue("name")))
+		return
+	}
+	if p := principalFrom(r); !p.canAccess(light.Name) {
+		forbidden(w, p, light.Name)
+		return
+	}
+
+	var patch api.StatePatch
+	if err := readJSON(r, &patch); err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	state := scene.State{On: patch.On, Brightness: patch.Brightness, Temperature: patch.Temperature}
+	if err := validateState(state); err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	if patch.Mired != nil {
+		if patch.Temperature != nil {
+			writeError(w, http.StatusBadRequest, fmt.Errorf("only one of temperature and mired may be set"))
+			return
+		}
+		if err := keylight.ValidateMired(*patch.Mired); err != nil {
+			writeError(w, http.StatusBadRequest, err)
+			return
+		}
+	}
+
+	if err := s.applyPatch(r.Context(), light.Light, state, patch.Mired); err != nil {
+		writeError(w, http.StatusBadGateway, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, s.apiLight(*light))
+}
+
+// handleMetrics serves the metrics of every light, and is therefore only
+// available to tokens that may access every light.
+func (s *Server) handleMetrics(w http.ResponseWriter, r *http.Request) {
+	if p := principalFrom(r); p.lights != nil {
+		writeError(w, http.StatusForbidden, fmt.Errorf("token '%s' may not access the metrics of every light", p.name))
+		return
+	}
+	s.config.Metrics.Handler().ServeHTTP(w, r)
+}
+
+func (s *Server) handleListGroups(w http.ResponseWriter, r *http.Request) {
+	p := principalFrom(r)
+	groups := make([]api.Group, 0, len(s.config.Groups))
+	for _, group := range s.config.Groups {
+		members, err := keylight.ExpandGroup(s.config.Groups, group.Name)
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, err)
+			return
+		}
+		if !p.canAccessAll(members) {
+			continue
+		}
+		groups = append(groups, api.Group{Name: group.Name, Lights: members, Groups: group.Groups})
+	}
+	writeJSON(w, http.StatusOK, groups)
+}
+
+func (s *Server) handleToggleGroup(w http.ResponseWriter, r *http.Request) {
+	name := r.PathValue("name")
+	if keylight.FindGroup(s.config.Groups, name) == nil {
+		writeError(w, http.StatusNotFound, fmt.Errorf("group '%s' not found", name))
+		return
+	}
+
+	var req api.ToggleRequest
+	if err := readJSON(r, &req); err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	if req.Mode == "" {
+		req.Mode = string(keylight.ToggleAnyOnTurnsAllOff)
+	}
+	mode, err := keylight.ParseToggleMode(req.Mode)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	members, err := keylight.ExpandGroup(s.config.Groups, name)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	p := principalFrom(r)
+	for _, member := range members {
+		if !p.canAccess(member) {
+			forbidden(w, p, member)
+			return
+		}
+	}
+
+	// Decide from the cached state; lights that are offline are skipped.
+	var (
+		lights []keylight.LightConfig
+		on     []bool
+	)
+	for _, member := range members {
+		light := s.findLight(member)
+		if entry := s.cache.get(member); light != nil && entry.online {
+			lights = append(lights, *light)
+			on = append(on, entry.detail.On == 1)
+		}
+	}
+
+	targets := make([]scene.Target, len(lights))
+	for i, next := range keylight.Toggle(mode, on) {
+		targets[i] = scene.Target{Light: lights[i].Light, State: scene.State{On: &next}}
+	}
+
+	errs := s.applyTargets(r.Context(), targets, 0)
+	writeJSON(w, http.StatusOK, s.apiLights(lights, errs))
+}
+
+func (s *Server) handleListScenes(w http.ResponseWriter, r *http.Request) {
+	p := principalFrom(r)
+	scenes := make([]api.Scene, 0, len(s.config.Scenes))
+	for _, sc := range s.config.Scenes {
+		if s.canAccessScene(p, sc) {
+			scenes = append(scenes, apiScene(sc))
+		}
+	}
+	writeJSON(w, http.StatusOK, scenes)
+}
+
+func (s *Server) handleApplyScene(w http.ResponseWriter, r *http.Request) {
+	sc := scene.Find(s.config.Scenes, r.PathValue("name"))
+	if sc == nil {
+		writeError(w, http.StatusNotFound, fmt.Errorf("scene '%s' not found", r.PathValue("name")))
+		return
+	}
+
+	var req api.ApplySceneRequest
+	if err := readJSON(r, &req); err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	var fade time.Duration
+	if req.Fade != "" {
+		var err error
+		if fade, err = time.ParseDuration(req.Fade); err != nil {
+			writeError(w, http.StatusBadRequest, fmt.Errorf("invalid fade: %w", err))
+			return
+		}
+	}
+
+	targets, err := scene.Resolve(*sc, s.config.Lights, s.config.Groups)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	p := principalFrom(r)
+	lights := make([]keylight.LightConfig, 0, len(targets))
+	for _, target := range targets {
+		if !p.canAccess(target.Light.Name) {
+			forbidden(w, p, target.Light.Name)
+			return
+		}
+		lights = append(lights, *s.findLight(target.Light.Name))
+	}
+
+	errs := s.applyTargets(r.Context(), targets, fade)
+	s.events.publish(api.Event{Type: api.EventSceneApplied, Time: time.Now(), Scene: sc.Name})
+	writeJSON(w, http.StatusOK, s.apiLights(lights, errs))
+}
+
+func validateState(state scene.State) error {
+	if state.Brightness != nil {
+		if err := keylight.ValidateBrightness(*state.Brightness); err != nil {
+			return err
+		}
+	}
+	if state.Temperature != nil {
+		if err := keylight.ValidateTemperature(*state.Temperature); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// readJSON decodes the request body into v. An empty body leaves v unchanged.
+// The body must be declared as JSON, which browsers cannot send cross-site
+// without a CORS preflight, so that web pages cannot change the lights.
+func readJSON(r *http.Request, v any) error {
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if mediaType != "application/json" {
+		return fmt.Errorf("unsupported content type '%s', expected application/json", r.Header.Get("Content-Type"))
+	}
+
+	err := json.NewDecoder(r.Body).Decode(v)
+	if err != nil && !errors.Is(err, io.EOF) {
+		return fmt.Errorf("invalid request body: %w", err)
+	}
+	return nil
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+func writeError(w http.ResponseWriter, status int, err error) {
+	writeJSON(w, status, api.Error{Error: err.Error()})
+}
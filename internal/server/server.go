@@ -0,0 +1,382 @@
+// Package server implements the keylightctl daemon: a cached, periodically
+// refreshed view of every configured light behind an HTTP API.
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/eckertalex/keylightctl/api"
+	"github.com/eckertalex/keylightctl/internal/alarm"
+	"github.com/eckertalex/keylightctl/internal/calendar"
+	"github.com/eckertalex/keylightctl/internal/follow"
+	"github.com/eckertalex/keylightctl/internal/keylight"
+	"github.com/eckertalex/keylightctl/internal/metrics"
+	"github.com/eckertalex/keylightctl/internal/scene"
+	"github.com/eckertalex/keylightctl/internal/schedule"
+	"github.com/eckertalex/keylightctl/internal/watch"
+)
+
+type Config struct {
+	Lights []keylight.LightConfig
+	Groups []keylight.GroupConfig
+	Scenes []scene.Scene
+	// Refresh is the interval at which the state of every light is polled.
+	Refresh time.Duration
+	// EventBuffer is the number of events buffered per subscriber.
+	EventBuffer    int
+	SlowSubscriber SlowSubscriberPolicy
+	// Tokens, if any, are required as bearer tokens on requests that do not
+	// arrive over the Unix socket.
+	Tokens []TokenConfig
+	// AuditLog, if set, receives an entry for every request that changes a
+	// light.
+	AuditLog *log.Logger
+	// Metrics, if set, is kept up to date with the state of the lights and
+	// served on /metrics.
+	Metrics *metrics.Metrics
+	// Schedules and Calendars are run by the daemon.
+	Schedules []schedule.Schedule
+	Calendars []calendar.Calendar
+}
+
+type token struct {
+	secret    string
+	principal *principal
+}
+
+type Server struct {
+	config Config
+	client keylight.Client
+	cache  *cache
+	events *hub
+	tokens []token
+
+	// locks serializes writes per light, so that concurrent requests, for
+	// example a fade and a toggle, do not interleave.
+	locks map[string]*sync.Mutex
+	// mirrors propagates the state of every followed light to its followers,
+	// by leader name.
+	mirrors map[string]*follow.Mirror
+
+	mux *http.ServeMux
+}
+
+func New(config Config, client keylight.Client) *Server {
+	s := &Server{
+		config:  config,
+		client:  client,
+		cache:   newCache(),
+		events:  newHub(config.EventBuffer, config.SlowSubscriber),
+		locks:   make(map[string]*sync.Mutex, len(config.Lights)),
+		mirrors: make(map[string]*follow.Mirror),
+		mux:     http.NewServeMux(),
+	}
+	for _, light := range config.Lights {
+		s.locks[light.Name] = &sync.Mutex{}
+	}
+	for leader, followers := range follow.Followers(config.Lights) {
+		s.mirrors[leader] = follow.NewMirror(followers, s.updateFollower)
+	}
+	for _, t := range config.Tokens {
+		s.tokens = append(s.tokens, token{secret: t.Token, principal: newPrincipal(t, config.Groups)})
+	}
+	s.routes()
+	return s
+}
+
+func (s *Server) Handler() http.Handler {
+	return s.authenticate(s.mux)
+}
+
+// Run keeps the cached state of every light up to date, mirrors followed
+// lights to their followers and runs the schedules and calendars until ctx
+// is done. It returns once the calendars have reverted the lights of
+// running events.
+func (s *Server) Run(ctx context.Context) {
+	go schedule.NewRunner(s.config.Schedules, s.runSchedule).Run(ctx)
+	for _, mirror := range s.mirrors {
+		go mirror.Run(ctx)
+	}
+
+	calendarsDone := make(chan struct{})
+	defer func() { <-calendarsDone }()
+	go func() {
+		defer close(calendarsDone)
+		calendar.NewRunner(s.config.Calendars, s.startCalendar).Run(ctx)
+	}()
+
+	lights := make([]keylight.Light, len(s.config.Lights))
+	for i, light := range s.config.Lights {
+		lights[i] = light.Light
+	}
+
+	// Every poll is compared with the cache, which holds the writes of the
+	// daemon, rather than with the previous poll.
+	watcher := watch.New(s.client, lights, s.config.Refresh)
+	watcher.Unchanged = true
+	watcher.Run(ctx, func(event watch.Event) {
+		if event.Type == watch.EventOffline {
+			s.recordError(event.Light.Name, event.Err)
+			return
+		}
+		s.recordState(event.Light.Name, event.Current)
+	})
+}
+
+func (s *Server) runSchedule(ctx context.Context, sc schedule.Schedule, now time.Time) error {
+	targets, err := sc.Targets(s.config.Scenes, s.config.Lights, s.config.Groups)
+	if err != nil {
+		return err
+	}
+	if sc.Sunrise > 0 {
+		// Ramp up in the background, so that other schedules run meanwhile.
+		end, err := sc.SunriseEnd(now)
+		if err != nil {
+			return err
+		}
+		go alarm.NewSunrise(lockedClient{s}, targets, end, sc.Sunrise).Run(ctx)
+		return nil
+	}
+
+	var errs []error
+	for name, err := range s.applyTargets(ctx, targets, sc.Fade) {
+		errs = append(errs, fmt.Errorf("%s: %w", name, err))
+	}
+	return errors.Join(errs...)
+}
+
+// startCalendar applies the scene of a calendar and returns a function that
+// restores the previous state of its lights.
+func (s *Server) startCalendar(ctx context.Context, c calendar.Calendar) (func(context.Context) error, error) {
+	sc := scene.Find(s.config.Scenes, c.Scene)
+	if sc == nil {
+		return nil, fmt.Errorf("scene '%s' not found", c.Scene)
+	}
+	targets, err := scene.Resolve(*sc, s.config.Lights, s.config.Groups)
+	if err != nil {
+		return nil, err
+	}
+	return calendar.Start(ctx, calendarLights{s}, targets, c.Fade)
+}
+
+// calendarLights reads and changes the lights of calendar events while
+// holding their write locks.
+type calendarLights struct {
+	s *Server
+}
+
+func (l calendarLights) Capture(light keylight.Light) (keylight.LightDetail, error) {
+	return l.s.refresh(light)
+}
+
+func (l calendarLights) Apply(ctx context.Context, target scene.Target, fade time.Duration) error {
+	return l.s.apply(ctx, target.Light, target.State, fade)
+}
+
+func (l calendarLights) Restore(light keylight.Light, detail keylight.LightDetail) error {
+	return l.s.restore(light, detail)
+}
+
+// recordState caches the state of a light and publishes an event if it came
+// online or its state changed. The followers of the light are moved along.
+func (s *Server) recordState(name string, detail keylight.LightDetail) {
+	prev := s.cache.setState(name, detail)
+	if s.config.Metrics != nil {
+		s.config.Metrics.SetState(name, detail)
+	}
+	if mirror, ok := s.mirrors[name]; ok && (!prev.online || prev.detail != detail) {
+		mirror.Set(detail)
+	}
+
+	switch {
+	case !prev.online:
+		s.events.publish(api.Event{Type: api.EventOnline, Time: time.Now(), Light: name, State: apiState(detail)})
+	case prev.detail != detail:
+		s.events.publish(api.Event{
+			Type:     api.EventStateChanged,
+			Time:     time.Now(),
+			Light:    name,
+			State:    apiState(detail),
+			Previous: apiState(prev.detail),
+		})
+	}
+}
+
+// recordError marks a light as offline and publishes an event if it was
+// online or had not been seen yet.
+func (s *Server) recordError(name string, err error) {
+	prev := s.cache.setError(name, err)
+	if s.config.Metrics != nil {
+		s.config.Metrics.SetOffline(name)
+	}
+
+	if prev.online || !prev.known {
+		s.events.publish(api.Event{Type: api.EventOffline, Time: time.Now(), Light: name, Error: err.Error()})
+	}
+}
+
+func (s *Server) findLight(name string) *keylight.LightConfig {
+	for i := range s.config.Lights {
+		if s.config.Lights[i].Name == name {
+			return &s.config.Lights[i]
+		}
+	}
+	return nil
+}
+
+// apply moves a light to state while holding its write lock, and records the
+// resulting state.
+func (s *Server) apply(ctx context.Context, light keylight.Light, state scene.State, fade time.Duration) error {
+	lock := s.locks[light.Name]
+	lock.Lock()
+	defer lock.Unlock()
+
+	status, err := scene.ApplyState(ctx, s.client, light.IP, state, fade)
+	if err != nil {
+		return err
+	}
+	if len(status.Lights) > 0 {
+		s.recordState(light.Name, status.Lights[0])
+	}
+	return nil
+}
+
+// updateFollower moves a follower to the state derived from its leader,
+// unless it is already known to be there. Since followers never write to
+// their leaders and follow relations are acyclic, changes cannot propagate
+// in a loop.
+func (s *Server) updateFollower(f follow.Follower, detail keylight.LightDetail) error {
+	if entry := s.cache.get(f.Light.Name); entry.online && entry.detail == detail {
+		return nil
+	}
+	return s.restore(f.Light, detail)
+}
+
+// applyPatch moves a light to state like apply, but with the temperature
+// given exactly in mired if set.
+func (s *Server) applyPatch(ctx context.Context, light keylight.Light, state scene.State, mired *int) error {
+	if mired == nil {
+		return s.apply(ctx, light, state, 0)
+	}
+
+	lock := s.locks[light.Name]
+	lock.Lock()
+	defer lock.Unlock()
+
+	var current keylight.LightDetail
+	if state.On == nil {
+		status, err := s.client.GetLight(light.IP)
+		if err != nil {
+			return err
+		}
+		if len(status.Lights) == 0 {
+			return errors.New("empty status")
+		}
+		current = status.Lights[0]
+	}
+	detail := state.Detail(current)
+	detail.Temperature = *mired
+
+	status, err := s.client.UpdateLight(light.IP, detail)
+	if err != nil {
+		return err
+	}
+	if len(status.Lights) > 0 {
+		s.recordState(light.Name, status.Lights[0])
+	}
+	return nil
+}
+
+// restore moves a light back to a previously recorded state while holding
+// its write lock.
+func (s *Server) restore(light keylight.Light, detail keylight.LightDetail) error {
+	_, err := s.update(light, detail)
+	return err
+}
+
+// update sets the state of a light while holding its write lock, and records
+// the resulting state.
+func (s *Server) update(light keylight.Light, detail keylight.LightDetail) (*keylight.LightStatus, error) {
+	lock := s.locks[light.Name]
+	lock.Lock()
+	defer lock.Unlock()
+
+	status, err := s.client.UpdateLight(light.IP, detail)
+	if err != nil {
+		return nil, err
+	}
+	if len(status.Lights) > 0 {
+		s.recordState(light.Name, status.Lights[0])
+	}
+	return status, nil
+}
+
+// refresh reads the state of a light while holding its write lock, and
+// records it, for callers that must not act on a cached state.
+func (s *Server) refresh(light keylight.Light) (keylight.LightDetail, error) {
+	lock := s.locks[light.Name]
+	lock.Lock()
+	defer lock.Unlock()
+
+	status, err := s.client.GetLight(light.IP)
+	if err == nil && len(status.Lights) == 0 {
+		err = errors.New("empty status")
+	}
+	if err != nil {
+		s.recordError(light.Name, err)
+		return keylight.LightDetail{}, err
+	}
+	s.recordState(light.Name, status.Lights[0])
+	return status.Lights[0], nil
+}
+
+// lockedClient is a keylight.Client for the configured lights whose updates
+// go through update, so that writers driving the lights through a client,
+// such as sunrises, take the write locks and keep the cache up to date.
+type lockedClient struct {
+	s *Server
+}
+
+func (c lockedClient) GetLight(ip string) (*keylight.LightStatus, error) {
+	return c.s.client.GetLight(ip)
+}
+
+func (c lockedClient) UpdateLight(ip string, settings keylight.LightDetail) (*keylight.LightStatus, error) {
+	for _, light := range c.s.config.Lights {
+		if light.IP == ip {
+			return c.s.update(light.Light, settings)
+		}
+	}
+	return nil, fmt.Errorf("no light configured at %s", ip)
+}
+
+// applyTargets applies every target in parallel and returns the error of each
+// light that failed, keyed by name.
+func (s *Server) applyTargets(ctx context.Context, targets []scene.Target, fade time.Duration) map[string]error {
+	var (
+		wg   sync.WaitGroup
+		mu   sync.Mutex
+		errs = make(map[string]error)
+	)
+
+	for _, target := range targets {
+		wg.Add(1)
+		go func(target scene.Target) {
+			defer wg.Done()
+			if err := s.apply(ctx, target.Light, target.State, fade); err != nil {
+				mu.Lock()
+				errs[target.Light.Name] = err
+				mu.Unlock()
+			}
+		}(target)
+	}
+	wg.Wait()
+
+	return errs
+}
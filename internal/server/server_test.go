@@ -0,0 +1,147 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/eckertalex/keylightctl/internal/calendar"
+	"github.com/eckertalex/keylightctl/internal/keylight"
+	"github.com/eckertalex/keylightctl/internal/keylighttest"
+	"github.com/eckertalex/keylightctl/internal/scene"
+	"github.com/eckertalex/keylightctl/internal/schedule"
+)
+
+func TestSunriseIsRecorded(t *testing.T) {
+	lights := []keylight.LightConfig{{Light: keylight.Light{Name: "Left", IP: "10.0.0.1"}}}
+	fake := keylighttest.NewLights(map[string]keylight.LightDetail{"10.0.0.1": {}})
+	s := New(Config{Lights: lights}, fake)
+
+	brightness, temperature := 80, 4000
+	sc := schedule.Schedule{
+		Name:       "wake",
+		At:         "07:00",
+		LightState: scene.LightState{Light: "Left", State: scene.State{Brightness: &brightness, Temperature: &temperature}},
+		Sunrise:    30 * time.Minute,
+	}
+
+	sub := s.events.subscribe()
+	defer s.events.unsubscribe(sub)
+
+	// A run caught up on long after it was due ends right away.
+	if err := s.runSchedule(context.Background(), sc, time.Now()); err != nil {
+		t.Fatal(err)
+	}
+
+	want := keylight.LightDetail{On: 1, Brightness: 80, Temperature: 250}
+	select {
+	case event := <-sub.events:
+		if event.Light != "Left" {
+			t.Errorf("got an event for light '%s', want 'Left'", event.Light)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for the sunrise to be recorded")
+	}
+	if entry := s.cache.get("Left"); !entry.online || entry.detail != want {
+		t.Errorf("cached %+v, want %+v", entry.detail, want)
+	}
+}
+
+// waitFor polls cond until it holds.
+func waitFor(t *testing.T, what string, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for %s", what)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestCacheFollowsChangesBack(t *testing.T) {
+	const ip = "10.0.0.1"
+	light := keylight.LightConfig{Light: keylight.Light{Name: "Left", IP: ip}}
+	before := keylight.LightDetail{On: 1, Brightness: 20, Temperature: 200}
+	fake := keylighttest.NewLights(map[string]keylight.LightDetail{ip: before})
+	s := New(Config{Lights: []keylight.LightConfig{light}, Refresh: 10 * time.Millisecond}, fake)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		s.Run(ctx)
+	}()
+	defer func() {
+		cancel()
+		<-done
+	}()
+
+	waitFor(t, "the light to come online", func() bool { return s.cache.get("Left").online })
+
+	// The daemon changes the light, and another app changes it back before
+	// the next poll.
+	if err := s.restore(light.Light, keylight.LightDetail{On: 1, Brightness: 90, Temperature: 200}); err != nil {
+		t.Fatal(err)
+	}
+	fake.Set(ip, before)
+
+	waitFor(t, "the cache to catch up", func() bool { return s.cache.get("Left").detail == before })
+
+	// Unchanged polls keep the cache fresh.
+	updated := s.cache.get("Left").updatedAt
+	waitFor(t, "an unchanged poll", func() bool { return s.cache.get("Left").updatedAt.After(updated) })
+}
+
+// unreadableLights are fake lights of which one can be changed but not read.
+type unreadableLights struct {
+	*keylighttest.Lights
+	ip string
+}
+
+func (l unreadableLights) GetLight(ip string) (*keylight.LightStatus, error) {
+	if ip == l.ip {
+		return nil, errors.New("read failed")
+	}
+	return l.Lights.GetLight(ip)
+}
+
+func TestCalendarChangesOnlyCapturedLights(t *testing.T) {
+	lights := []keylight.LightConfig{
+		{Light: keylight.Light{Name: "Left", IP: "10.0.0.1"}},
+		{Light: keylight.Light{Name: "Right", IP: "10.0.0.2"}},
+	}
+	before := keylight.LightDetail{On: 1, Brightness: 20, Temperature: 200}
+	fake := keylighttest.NewLights(map[string]keylight.LightDetail{"10.0.0.1": before, "10.0.0.2": before})
+	// The scene sets the light completely, so that applying it does not
+	// need to read the light.
+	on, brightness := true, 80
+	state := scene.State{On: &on, Brightness: &brightness}
+	s := New(Config{
+		Lights: lights,
+		Scenes: []scene.Scene{{Name: "meeting", Lights: []scene.LightState{
+			{Light: "Left", State: state},
+			{Light: "Right", State: state},
+		}}},
+	}, unreadableLights{fake, "10.0.0.2"})
+
+	revert, err := s.startCalendar(context.Background(), calendar.Calendar{Name: "work", Scene: "meeting"})
+	if err == nil || !strings.Contains(err.Error(), "Right") {
+		t.Errorf("got error %v, want one for the unreadable light", err)
+	}
+	if got := fake.Get("10.0.0.1").Brightness; got != brightness {
+		t.Errorf("Left has brightness %d during the event, want %d", got, brightness)
+	}
+
+	if err := revert(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if got := fake.Get("10.0.0.1"); got != before {
+		t.Errorf("Left is %+v after the event, want %+v", got, before)
+	}
+	if updates := fake.Updates("10.0.0.2"); len(updates) != 0 {
+		t.Errorf("Right, which cannot be restored, was changed: %+v", updates)
+	}
+}
@@ -0,0 +1,145 @@
+package watch
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/eckertalex/keylightctl/internal/keylight"
+)
+
+type EventType string
+
+const (
+	// EventOnline is emitted when a light answers for the first time, or
+	// again after being offline. Current holds its state.
+	EventOnline EventType = "online"
+	// EventOffline is emitted when a light stops answering. Err holds the
+	// reason.
+	EventOffline EventType = "offline"
+	// EventChanged is emitted when the state of an online light changes.
+	EventChanged EventType = "changed"
+	// EventUnchanged is emitted, if enabled, when an online light is in the
+	// same state as at the previous poll.
+	EventUnchanged EventType = "unchanged"
+)
+
+type Event struct {
+	Time     time.Time
+	Type     EventType
+	Light    keylight.Light
+	Previous keylight.LightDetail
+	Current  keylight.LightDetail
+	Err      error
+}
+
+// Watcher polls a set of lights and reports changes to their state and
+// reachability.
+type Watcher struct {
+	Client   keylight.Client
+	Lights   []keylight.Light
+	Interval time.Duration
+	// MaxBackoff caps the polling interval of unreachable lights, which
+	// doubles after every failed poll.
+	MaxBackoff time.Duration
+	// Unchanged enables EventUnchanged, for owners that change the lights
+	// themselves: a light can be changed and changed back by someone else
+	// between two polls, so they compare every poll with the state they
+	// know instead.
+	Unchanged bool
+}
+
+func New(client keylight.Client, lights []keylight.Light, interval time.Duration) *Watcher {
+	return &Watcher{
+		Client:     client,
+		Lights:     lights,
+		Interval:   interval,
+		MaxBackoff: max(interval, time.Minute),
+	}
+}
+
+// Run polls every light until ctx is done. emit is called for every event,
+// one at a time.
+func (w *Watcher) Run(ctx context.Context, emit func(Event)) {
+	events := make(chan Event)
+
+	var wg sync.WaitGroup
+	for _, light := range w.Lights {
+		wg.Add(1)
+		go func(light keylight.Light) {
+			defer wg.Done()
+			w.watchLight(ctx, light, events)
+		}(light)
+	}
+
+	go func() {
+		wg.Wait()
+		close(events)
+	}()
+
+	for event := range events {
+		emit(event)
+	}
+}
+
+func (w *Watcher) watchLight(ctx context.Context, light keylight.Light, events chan<- Event) {
+	var (
+		online  bool
+		known   bool
+		current keylight.LightDetail
+		delay   = w.Interval
+	)
+
+	for {
+		detail, err := w.poll(light)
+
+		var event *Event
+		switch {
+		case err != nil:
+			delay = min(delay*2, w.MaxBackoff)
+			if online || !known {
+				event = &Event{Type: EventOffline, Previous: current, Err: err}
+			}
+			online = false
+		case !online:
+			delay = w.Interval
+			online = true
+			event = &Event{Type: EventOnline, Previous: current, Current: detail}
+			current = detail
+		case detail != current:
+			event = &Event{Type: EventChanged, Previous: current, Current: detail}
+			current = detail
+		case w.Unchanged:
+			event = &Event{Type: EventUnchanged, Previous: current, Current: detail}
+		}
+		known = true
+
+		if event != nil {
+			event.Time = time.Now()
+			event.Light = light
+			select {
+			case events <- *event:
+			case <-ctx.Done():
+				return
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(delay):
+		}
+	}
+}
+
+func (w *Watcher) poll(light keylight.Light) (keylight.LightDetail, error) {
+	status, err := w.Client.GetLight(light.IP)
+	if err != nil {
+		return keylight.LightDetail{}, err
+	}
+	if len(status.Lights) == 0 {
+		return keylight.LightDetail{}, errors.New("empty status")
+	}
+	return status.Lights[0], nil
+}
@@ -2,6 +2,7 @@ package tui
 
 import (
 	"errors"
+	"slices"
 
 	"github.com/charmbracelet/bubbles/progress"
 	tea "github.com/charmbracelet/bubbletea"
@@ -16,13 +17,32 @@ type Light struct {
 	Temperature int
 }
 
+type Group struct {
+	Name      string
+	Lights    []int
+	Collapsed bool
+}
+
+// row is a selectable line in the TUI: either a group header (light == -1) or
+// a light, optionally shown as a member of a group (group == -1 otherwise).
+type row struct {
+	group int
+	light int
+}
+
 type Model struct {
 	GlobalOn bool
 
 	Lights []Light
+	Groups []Group
+
+	// Ungrouped holds the indexes of lights that are not in any group.
+	Ungrouped []int
 
 	Cursor int
 
+	client keylight.Client
+
 	brightnessBar  progress.Model
 	temperatureBar progress.Model
 
@@ -33,14 +53,15 @@ type Model struct {
 func (m Model) Init() tea.Cmd {
 	var cmds []tea.Cmd
 	for i, light := range m.Lights {
-		cmds = append(cmds, fetchLightStatus(i, light.IP))
+		cmds = append(cmds, fetchLightStatus(m.client, i, light.IP))
 	}
 	return tea.Batch(cmds...)
 }
 
-func NewModel(configs []keylight.LightConfig) Model {
+func NewModel(configs []keylight.LightConfig, groupConfigs []keylight.GroupConfig, client keylight.Client) Model {
 	pb := progress.New(progress.WithDefaultGradient())
 	lights := make([]Light, len(configs))
+	indexes := make(map[string]int, len(configs))
 
 	for i, cfg := range configs {
 		lights[i] = Light{
@@ -50,18 +71,106 @@ func NewModel(configs []keylight.LightConfig) Model {
 			Brightness:  20,
 			Temperature: 5000,
 		}
+		indexes[cfg.Name] = i
 	}
+
+	grouped := make([]bool, len(lights))
+	var groups []Group
+	for _, cfg := range groupConfigs {
+		names, err := keylight.ExpandGroup(groupConfigs, cfg.Name)
+		if err != nil {
+			continue
+		}
+
+		group := Group{Name: cfg.Name}
+		for _, name := range names {
+			if i, ok := indexes[name]; ok {
+				group.Lights = append(group.Lights, i)
+				grouped[i] = true
+			}
+		}
+		groups = append(groups, group)
+	}
+
+	var ungrouped []int
+	for i := range lights {
+		if !grouped[i] {
+			ungrouped = append(ungrouped, i)
+		}
+	}
+
 	return Model{
 		GlobalOn:       false,
 		Lights:         lights,
+		Groups:         groups,
+		Ungrouped:      ungrouped,
 		Cursor:         0,
+		client:         client,
 		brightnessBar:  pb,
 		temperatureBar: pb,
 	}
 }
 
-func initialModel(configs []keylight.LightConfig) Model {
-	return NewModel(configs)
+func initialModel(configs []keylight.LightConfig, groupConfigs []keylight.GroupConfig, client keylight.Client) Model {
+	return NewModel(configs, groupConfigs, client)
+}
+
+// rows returns the selectable lines in display order. Lights of collapsed
+// groups are hidden.
+func (m Model) rows() []row {
+	var rows []row
+	for g, group := range m.Groups {
+		rows = append(rows, row{group: g, light: -1})
+		if group.Collapsed {
+			continue
+		}
+		for _, i := range group.Lights {
+			rows = append(rows, row{group: g, light: i})
+		}
+	}
+	for _, i := range m.Ungrouped {
+		rows = append(rows, row{group: -1, light: i})
+	}
+	return rows
+}
+
+func (m Model) currentRow() (row, bool) {
+	rows := m.rows()
+	if m.Cursor >= len(rows) {
+		return row{}, false
+	}
+	return rows[m.Cursor], true
+}
+
+// selectedLights returns the indexes of the lights under the cursor: a single
+// light, or all members of a group.
+func (m Model) selectedLights() []int {
+	r, ok := m.currentRow()
+	if !ok {
+		return nil
+	}
+	if r.light == -1 {
+		return m.Groups[r.group].Lights
+	}
+	return []int{r.light}
+}
+
+func (m Model) groupOn(g int) bool {
+	return slices.ContainsFunc(m.Groups[g].Lights, func(i int) bool {
+		return m.Lights[i].On
+	})
+}
+
+func lightSettings(light Light) keylight.LightDetail {
+	on := 0
+	if light.On {
+		on = 1
+	}
+	return keylight.LightDetail{
+		On:          on,
+		Brightness:  light.Brightness,
+		Temperature: keylight.KelvinToMired(light.Temperature),
+	}
 }
 
 type lightStatusMsg struct {
@@ -76,10 +185,9 @@ type lightUpdateMsg struct {
 	err    error
 }
 
-func fetchLightStatus(index int, ip string) tea.Cmd {
+func fetchLightStatus(client keylight.Client, index int, ip string) tea.Cmd {
 	return func() tea.Msg {
-		controller := keylight.NewController()
-		status, err := controller.GetLight(ip)
+		status, err := client.GetLight(ip)
 		var detail keylight.LightDetail
 		if err == nil && len(status.Lights) > 0 {
 			detail = status.Lights[0]
@@ -90,10 +198,9 @@ func fetchLightStatus(index int, ip string) tea.Cmd {
 	}
 }
 
-func updateLight(index int, ip string, settings keylight.LightDetail) tea.Cmd {
+func updateLight(client keylight.Client, index int, ip string, settings keylight.LightDetail) tea.Cmd {
 	return func() tea.Msg {
-		controller := keylight.NewController()
-		status, err := controller.UpdateLight(ip, settings)
+		status, err := client.UpdateLight(ip, settings)
 		var detail keylight.LightDetail
 		if err == nil && len(status.Lights) > 0 {
 			detail = status.Lights[0]
@@ -5,8 +5,8 @@ import (
 	"github.com/eckertalex/keylightctl/internal/keylight"
 )
 
-func Run(lightsConfig []keylight.LightConfig) error {
-	p := tea.NewProgram(initialModel(lightsConfig), tea.WithAltScreen())
+func Run(lightsConfig []keylight.LightConfig, groupsConfig []keylight.GroupConfig, client keylight.Client) error {
+	p := tea.NewProgram(initialModel(lightsConfig, groupsConfig, client), tea.WithAltScreen())
 	_, err := p.Run()
 	return err
 }
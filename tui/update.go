@@ -21,7 +21,7 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			var cmds []tea.Cmd
 			for i := range m.Lights {
 				m.Lights[i].On = m.GlobalOn
-				cmds = append(cmds, fetchLightStatus(i, m.Lights[i].IP))
+				cmds = append(cmds, fetchLightStatus(m.client, i, m.Lights[i].IP))
 			}
 			return m, tea.Batch(cmds...)
 		case "g", "G":
@@ -29,17 +29,7 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			var cmds []tea.Cmd
 			for i := range m.Lights {
 				m.Lights[i].On = m.GlobalOn
-				settings := keylight.LightDetail{
-					On: func() int {
-						if m.GlobalOn {
-							return 1
-						}
-						return 0
-					}(),
-					Brightness:  m.Lights[i].Brightness,
-					Temperature: keylight.KelvinToMired(m.Lights[i].Temperature),
-				}
-				cmds = append(cmds, updateLight(i, m.Lights[i].IP, settings))
+				cmds = append(cmds, updateLight(m.client, i, m.Lights[i].IP, lightSettings(m.Lights[i])))
 			}
 			return m, tea.Batch(cmds...)
 		case "up", "k":
@@ -47,87 +37,69 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				m.Cursor--
 			}
 		case "down", "j":
-			if m.Cursor < len(m.Lights)-1 {
+			if m.Cursor < len(m.rows())-1 {
 				m.Cursor++
 			}
+		case "left", "h":
+			if r, ok := m.currentRow(); ok && r.group != -1 {
+				m.Groups[r.group].Collapsed = true
+				m.Cursor = slices.Index(m.rows(), row{group: r.group, light: -1})
+			}
+		case "right", "l":
+			if r, ok := m.currentRow(); ok && r.light == -1 {
+				m.Groups[r.group].Collapsed = false
+			}
 		case "enter":
-			idx := m.Cursor
-			m.Lights[idx].On = !m.Lights[idx].On
-			settings := keylight.LightDetail{
-				On: func() int {
-					if m.Lights[idx].On {
-						return 1
-					}
-					return 0
-				}(),
-				Brightness:  m.Lights[idx].Brightness,
-				Temperature: keylight.KelvinToMired(m.Lights[idx].Temperature),
+			r, ok := m.currentRow()
+			if !ok {
+				break
 			}
-			return m, updateLight(idx, m.Lights[idx].IP, settings)
-		case "+":
-			idx := m.Cursor
-			if m.Lights[idx].Brightness < 100 {
-				m.Lights[idx].Brightness += 5
+			if r.light == -1 {
+				members := m.Groups[r.group].Lights
+				on := make([]bool, len(members))
+				for j, i := range members {
+					on[j] = m.Lights[i].On
+				}
+				for j, next := range keylight.Toggle(keylight.ToggleAnyOnTurnsAllOff, on) {
+					m.Lights[members[j]].On = next
+				}
+				return m, m.updateLights(m.Groups[r.group].Lights)
 			}
-			settings := keylight.LightDetail{
-				On: func() int {
-					if m.Lights[idx].On {
-						return 1
-					}
-					return 0
-				}(),
-				Brightness:  m.Lights[idx].Brightness,
-				Temperature: keylight.KelvinToMired(m.Lights[idx].Temperature),
+			idx := r.light
+			m.Lights[idx].On = !m.Lights[idx].On
+			return m, m.updateLights([]int{idx})
+		case "+":
+			indexes := m.selectedLights()
+			for _, idx := range indexes {
+				if m.Lights[idx].Brightness < 100 {
+					m.Lights[idx].Brightness += 5
+				}
 			}
-			return m, updateLight(idx, m.Lights[idx].IP, settings)
+			return m, m.updateLights(indexes)
 		case "-":
-			idx := m.Cursor
-			if m.Lights[idx].Brightness > 0 {
-				m.Lights[idx].Brightness -= 5
-			}
-			settings := keylight.LightDetail{
-				On: func() int {
-					if m.Lights[idx].On {
-						return 1
-					}
-					return 0
-				}(),
-				Brightness:  m.Lights[idx].Brightness,
-				Temperature: keylight.KelvinToMired(m.Lights[idx].Temperature),
+			indexes := m.selectedLights()
+			for _, idx := range indexes {
+				if m.Lights[idx].Brightness > 0 {
+					m.Lights[idx].Brightness -= 5
+				}
 			}
-			return m, updateLight(idx, m.Lights[idx].IP, settings)
+			return m, m.updateLights(indexes)
 		case "n":
-			idx := m.Cursor
-			if m.Lights[idx].Temperature < 7000 {
-				m.Lights[idx].Temperature += 100
-			}
-			settings := keylight.LightDetail{
-				On: func() int {
-					if m.Lights[idx].On {
-						return 1
-					}
-					return 0
-				}(),
-				Brightness:  m.Lights[idx].Brightness,
-				Temperature: keylight.KelvinToMired(m.Lights[idx].Temperature),
+			indexes := m.selectedLights()
+			for _, idx := range indexes {
+				if m.Lights[idx].Temperature < 7000 {
+					m.Lights[idx].Temperature += 100
+				}
 			}
-			return m, updateLight(idx, m.Lights[idx].IP, settings)
+			return m, m.updateLights(indexes)
 		case "m":
-			idx := m.Cursor
-			if m.Lights[idx].Temperature > 2900 {
-				m.Lights[idx].Temperature -= 100
-			}
-			settings := keylight.LightDetail{
-				On: func() int {
-					if m.Lights[idx].On {
-						return 1
-					}
-					return 0
-				}(),
-				Brightness:  m.Lights[idx].Brightness,
-				Temperature: keylight.KelvinToMired(m.Lights[idx].Temperature),
+			indexes := m.selectedLights()
+			for _, idx := range indexes {
+				if m.Lights[idx].Temperature > 2900 {
+					m.Lights[idx].Temperature -= 100
+				}
 			}
-			return m, updateLight(idx, m.Lights[idx].IP, settings)
+			return m, m.updateLights(indexes)
 		}
 	case lightStatusMsg:
 		if msg.err != nil {
@@ -159,3 +131,11 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 
 	return m, nil
 }
+
+func (m Model) updateLights(indexes []int) tea.Cmd {
+	var cmds []tea.Cmd
+	for _, idx := range indexes {
+		cmds = append(cmds, updateLight(m.client, idx, m.Lights[idx].IP, lightSettings(m.Lights[idx])))
+	}
+	return tea.Batch(cmds...)
+}
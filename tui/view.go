@@ -36,6 +36,18 @@ func renderGlobalCard(globalOn bool) string {
 	return card.Render(globalText)
 }
 
+func renderGroupCard(group Group, isOn bool, isSelected bool) string {
+	card := applySelection(baseCardStyle(), isSelected)
+
+	marker := "▾"
+	if group.Collapsed {
+		marker = "▸"
+	}
+	header := fmt.Sprintf("%s %s (%d lights) %s", marker, group.Name, len(group.Lights), formatStatus(isOn))
+
+	return card.Render(lipgloss.NewStyle().Bold(true).Render(header))
+}
+
 func renderLightCard(light Light, isSelected bool, brightnessBar, temperatureBar Bar) string {
 	card := applySelection(baseCardStyle(), isSelected)
 
@@ -63,7 +75,7 @@ func renderFooter() string {
 		BorderForeground(lipgloss.Color("240")).
 		Foreground(lipgloss.Color("240"))
 
-	controlsText := "↑/k, ↓/j: Move | Enter: Toggle | g: Toggle all | r: Refresh\n+/-: Brightness | n/m: Temperature | q: Quit"
+	controlsText := "↑/k, ↓/j: Move | Enter: Toggle | g: Toggle all | r: Refresh\n+/-: Brightness | n/m: Temperature | ←/h, →/l: Collapse/Expand\nq: Quit"
 
 	return footerStyle.Render(controlsText)
 }
@@ -75,9 +87,14 @@ type Bar interface {
 func (m Model) View() string {
 	globalCard := renderGlobalCard(m.GlobalOn)
 
-	lightCards := make([]string, len(m.Lights))
-	for i, light := range m.Lights {
-		lightCards[i] = renderLightCard(light, i == m.Cursor, m.brightnessBar, m.temperatureBar)
+	rows := m.rows()
+	lightCards := make([]string, len(rows))
+	for i, r := range rows {
+		if r.light == -1 {
+			lightCards[i] = renderGroupCard(m.Groups[r.group], m.groupOn(r.group), i == m.Cursor)
+			continue
+		}
+		lightCards[i] = renderLightCard(m.Lights[r.light], i == m.Cursor, m.brightnessBar, m.temperatureBar)
 	}
 
 	footer := renderFooter()